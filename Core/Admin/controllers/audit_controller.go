@@ -0,0 +1,69 @@
+package controllers
+
+import (
+	"database/sql"
+	"fmt"
+	"golang-api-rest-swagger/Core/Admin/models"
+	"golang-api-rest-swagger/Core/Books/database"
+	"golang-api-rest-swagger/Core/Shared/respond"
+	"net/http"
+	"strconv"
+)
+
+// GetAuditLog handles the retrieval of the audit history of a record.
+// @Summary Get the audit history of a record
+// @Description Retrieve every recorded mutation of a record, oldest first. Requires the admin API key.
+// @Tags admin
+// @Produce json
+// @Security ApiKeyAuth
+// @Param entity query string true "Entity name, e.g. book" example(book)
+// @Param id query int true "Entity ID" example(1)
+// @Success 200 {array} models.AuditEntry
+// @Failure 400 {string} string "Invalid query parameters"
+// @Failure 401 {string} string "Unauthorized"
+// @Router /admin/audit [get]
+func GetAuditLog(w http.ResponseWriter, r *http.Request, db *sql.DB) {
+	w.Header().Set("Content-Type", "application/json")
+	entity := r.URL.Query().Get("entity")
+	if entity == "" {
+		respond.Error(w, "Invalid query parameters: entity is required", http.StatusBadRequest)
+		return
+	}
+	entityID, err := strconv.ParseInt(r.URL.Query().Get("id"), 10, 64)
+	if err != nil {
+		respond.Error(w, "Invalid query parameters: id must be an integer", http.StatusBadRequest)
+		return
+	}
+
+	rows, err := db.QueryContext(r.Context(), fmt.Sprintf(
+		"SELECT `id`, `entity`, `entity_id`, `action`, `actor`, `payload`, `created_at` "+
+			"FROM %s "+
+			"WHERE `entity` = ? AND `entity_id` = ? "+
+			"ORDER BY `id`", database.Table(database.AuditLog)), entity, entityID)
+	if err != nil {
+		respond.Error(w, fmt.Sprintf("Database query failed: %v", err), http.StatusInternalServerError)
+		return
+	}
+	defer rows.Close()
+
+	entries := []models.AuditEntry{}
+	for rows.Next() {
+		var entry models.AuditEntry
+		var payload []byte
+		if err := rows.Scan(&entry.ID, &entry.Entity, &entry.EntityID, &entry.Action, &entry.Actor, &payload, &entry.CreatedAt.Time); err != nil {
+			respond.Error(w, fmt.Sprintf("Failed to scan row: %v", err), http.StatusInternalServerError)
+			return
+		}
+		if payload != nil {
+			entry.Payload = payload
+		}
+		entries = append(entries, entry)
+	}
+
+	if err := rows.Err(); err != nil {
+		respond.Error(w, fmt.Sprintf("Error during row iteration: %v", err), http.StatusInternalServerError)
+		return
+	}
+
+	respond.JSON(w, r, http.StatusOK, entries)
+}
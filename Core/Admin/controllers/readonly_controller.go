@@ -0,0 +1,60 @@
+package controllers
+
+import (
+	"encoding/json"
+	"fmt"
+	"golang-api-rest-swagger/Core/Shared/middleware"
+	"golang-api-rest-swagger/Core/Shared/respond"
+	"net/http"
+)
+
+// ReadOnlyState is the request and response body of the read-only toggle.
+type ReadOnlyState struct {
+	Enabled *bool `json:"enabled" example:"true"`
+}
+
+// GetReadOnly handles the retrieval of the current read-only state.
+// @Summary Get read-only mode
+// @Description Report whether read-only mode is currently enabled. Requires the admin API key.
+// @Tags admin
+// @Produce json
+// @Security ApiKeyAuth
+// @Success 200 {object} ReadOnlyState
+// @Failure 401 {string} string "Unauthorized"
+// @Router /admin/readonly [get]
+func GetReadOnly(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	enabled := middleware.IsReadOnly()
+	respond.JSON(w, r, http.StatusOK, ReadOnlyState{Enabled: &enabled})
+}
+
+// SetReadOnly handles toggling read-only mode at runtime.
+// @Summary Toggle read-only mode
+// @Description Enable or disable read-only mode without a restart. Requires the admin API key.
+// @Tags admin
+// @Accept json
+// @Produce json
+// @Security ApiKeyAuth
+// @Param state body ReadOnlyState true "Desired read-only state"
+// @Success 200 {object} ReadOnlyState
+// @Failure 400 {string} string "Invalid request body"
+// @Failure 415 {string} string "Request body not sent as application/json"
+// @Failure 401 {string} string "Unauthorized"
+// @Router /admin/readonly [post]
+func SetReadOnly(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	var state ReadOnlyState
+	if err := json.NewDecoder(r.Body).Decode(&state); err != nil {
+		respond.Error(w, fmt.Sprintf("Invalid request body: %v", err), http.StatusBadRequest)
+		return
+	}
+	if state.Enabled == nil {
+		respond.Error(w, "Invalid request body: enabled is required", http.StatusBadRequest)
+		return
+	}
+
+	middleware.SetReadOnly(*state.Enabled)
+
+	enabled := middleware.IsReadOnly()
+	respond.JSON(w, r, http.StatusOK, ReadOnlyState{Enabled: &enabled})
+}
@@ -0,0 +1,68 @@
+package controllers
+
+import (
+	"database/sql"
+	"fmt"
+	"golang-api-rest-swagger/Core/Books/database"
+	"golang-api-rest-swagger/Core/Shared/respond"
+	"log"
+	"net/http"
+)
+
+// ResetResult is the response of ResetBooks.
+type ResetResult struct {
+	Deleted int64 `json:"deleted" example:"120"`
+}
+
+// ResetBooks handles wiping the books table for test and demo environments.
+// Every book, favorite and book audit entry is removed permanently and the id counter restarts at 1,
+// so the history of old books cannot be mistaken for that of new books reusing their ids.
+// @Summary Reset the books table
+// @Description Permanently delete every book, including soft-deleted ones, their favorites and audit entries, then reset
+// @Description AUTO_INCREMENT so ids restart at 1. Meant for test and demo environments: it is only routed
+// @Description when FEATURE_RESET is enabled and requires confirm=true. Requires the admin API key.
+// @Tags admin
+// @Produce json
+// @Security ApiKeyAuth
+// @Param confirm query bool true "Must be true"
+// @Success 200 {object} ResetResult
+// @Failure 400 {string} string "Reset not confirmed"
+// @Failure 401 {string} string "Unauthorized"
+// @Router /admin/books/reset [post]
+func ResetBooks(w http.ResponseWriter, r *http.Request, db *sql.DB) {
+	w.Header().Set("Content-Type", "application/json")
+	if r.URL.Query().Get("confirm") != "true" {
+		respond.Error(w, "Reset not confirmed: pass confirm=true to delete every book", http.StatusBadRequest)
+		return
+	}
+
+	// TRUNCATE is refused on a table referenced by a foreign key, so the rows are deleted instead.
+	var result ResetResult
+	err := database.WithTx(r.Context(), db, func(tx *sql.Tx) error {
+		if _, err := tx.ExecContext(r.Context(), fmt.Sprintf("DELETE FROM %s", database.Table(database.Favorites))); err != nil {
+			return err
+		}
+		res, err := tx.ExecContext(r.Context(), fmt.Sprintf("DELETE FROM %s", database.Table(database.Books)))
+		if err != nil {
+			return err
+		}
+		if result.Deleted, err = res.RowsAffected(); err != nil {
+			return err
+		}
+		_, err = tx.ExecContext(r.Context(), fmt.Sprintf("DELETE FROM %s WHERE `entity` = ?", database.Table(database.AuditLog)), database.EntityBook)
+		return err
+	})
+	if err != nil {
+		respond.Error(w, fmt.Sprintf("Database delete failed: %v", err), http.StatusInternalServerError)
+		return
+	}
+
+	// ALTER TABLE commits implicitly, so it cannot be part of the transaction above.
+	if _, err := db.ExecContext(r.Context(), fmt.Sprintf("ALTER TABLE %s AUTO_INCREMENT = 1", database.Table(database.Books))); err != nil {
+		respond.Error(w, fmt.Sprintf("Failed to reset AUTO_INCREMENT: %v", err), http.StatusInternalServerError)
+		return
+	}
+	log.Printf("Books table reset, %d books deleted", result.Deleted)
+
+	respond.JSON(w, r, http.StatusOK, result)
+}
@@ -0,0 +1,17 @@
+package models
+
+import (
+	"encoding/json"
+	"golang-api-rest-swagger/Core/Shared/timestamp"
+)
+
+// AuditEntry is a recorded mutation of an entity.
+type AuditEntry struct {
+	ID        int64           `json:"id" example:"42"`
+	Entity    string          `json:"entity" example:"book"`
+	EntityID  int64           `json:"entity_id" example:"1"`
+	Action    string          `json:"action" example:"update"`
+	Actor     string          `json:"actor" example:"admin"`
+	Payload   json.RawMessage `json:"payload" swaggertype:"object"`
+	CreatedAt timestamp.Time  `json:"created_at" swaggertype:"string" example:"2024-05-01T12:00:00Z"`
+}
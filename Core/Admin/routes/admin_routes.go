@@ -0,0 +1,32 @@
+package routes
+
+import (
+	"database/sql"
+	"github.com/gorilla/mux"
+	"golang-api-rest-swagger/Core/Admin/controllers"
+	"golang-api-rest-swagger/Core/Shared/auth"
+	"golang-api-rest-swagger/Core/Shared/config"
+	"golang-api-rest-swagger/Core/Shared/middleware"
+	"net/http"
+)
+
+// SetupRoutes defines the admin API routes. They are protected by the admin API key and are
+// deliberately kept outside the read-only guard so read-only mode can always be turned off.
+func SetupRoutes(r *mux.Router, db *sql.DB, cfg config.Config, keys auth.Keys) {
+	admin := r.PathPrefix("/admin").Subrouter()
+	admin.Use(auth.RequireAdmin(keys))
+
+	admin.HandleFunc("/readonly", controllers.GetReadOnly).Methods("GET")
+	admin.Handle("/readonly", middleware.NoStore(http.HandlerFunc(controllers.SetReadOnly))).Methods("POST")
+
+	admin.HandleFunc("/audit", func(w http.ResponseWriter, r *http.Request) {
+		controllers.GetAuditLog(w, r, db)
+	}).Methods("GET")
+
+	// Destructive, for test and demo environments only. Unlike the toggle it honours read-only mode.
+	if cfg.Features.Enabled(config.FeatureReset) {
+		admin.Handle("/books/reset", middleware.ReadOnly(middleware.NoStore(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			controllers.ResetBooks(w, r, db)
+		})))).Methods("POST")
+	}
+}
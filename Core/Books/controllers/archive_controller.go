@@ -0,0 +1,101 @@
+package controllers
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"github.com/gorilla/mux"
+	"golang-api-rest-swagger/Core/Books/database"
+	"golang-api-rest-swagger/Core/Shared/auth"
+	"golang-api-rest-swagger/Core/Shared/respond"
+	"net/http"
+	"strconv"
+)
+
+// Statuses of a book, selected with ?status= on GET /books. Archived books stay readable by id but
+// are left out of the lists unless asked for.
+const (
+	statusActive   = "active"
+	statusArchived = "archived"
+)
+
+// errSameStatus reports an archive or restore of a book already in the status it moves to.
+var errSameStatus = errors.New("book already in the target status")
+
+// ArchiveBook handles moving a book out of the default listing without deleting it.
+// @Summary Archive a book
+// @Description Archive an active book: it is left out of GET /books, unless status=archived is given, and of the
+// @Description index, years, feed and similar books, but stays readable, editable and deletable by id.
+// @Description Archiving an archived book returns 409 Conflict.
+// @Tags books
+// @Produce json
+// @Param id path int true "Book ID" example(1)
+// @Success 200 {string} string "Book archived successfully"
+// @Failure 400 {string} string "Invalid book ID"
+// @Failure 404 {string} string "Book not found"
+// @Failure 409 {string} string "Book already archived"
+// @Router /books/{id}/archive [post]
+func ArchiveBook(w http.ResponseWriter, r *http.Request, db *sql.DB) {
+	setBookArchived(w, r, db, true)
+}
+
+// RestoreBook handles moving an archived book back into the default listing.
+// @Summary Restore an archived book
+// @Description Restore an archived book to the active status. Restoring a book that is not archived returns
+// @Description 409 Conflict. Deleted books cannot be restored.
+// @Tags books
+// @Produce json
+// @Param id path int true "Book ID" example(1)
+// @Success 200 {string} string "Book restored successfully"
+// @Failure 400 {string} string "Invalid book ID"
+// @Failure 404 {string} string "Book not found"
+// @Failure 409 {string} string "Book not archived"
+// @Router /books/{id}/restore [post]
+func RestoreBook(w http.ResponseWriter, r *http.Request, db *sql.DB) {
+	setBookArchived(w, r, db, false)
+}
+
+// setBookArchived archives or restores a book, refusing to move it to the status it already has.
+func setBookArchived(w http.ResponseWriter, r *http.Request, db *sql.DB, archive bool) {
+	w.Header().Set("Content-Type", "application/json")
+	id, err := strconv.ParseInt(mux.Vars(r)["id"], 10, 64)
+	if err != nil {
+		respond.Error(w, "Invalid book ID", http.StatusBadRequest)
+		return
+	}
+
+	action, archivedAt := database.ActionRestore, "NULL"
+	if archive {
+		action, archivedAt = database.ActionArchive, "CURRENT_TIMESTAMP"
+	}
+
+	// Check the transition on the locked row and record it in the audit log in one transaction.
+	table := database.Table(database.Books)
+	err = database.WithTx(r.Context(), db, func(tx *sql.Tx) error {
+		var archived bool
+		if err := tx.QueryRowContext(r.Context(), fmt.Sprintf("SELECT `archived_at` IS NOT NULL FROM %s WHERE `id` = ? AND `deleted_at` IS NULL FOR UPDATE", table), id).Scan(&archived); err != nil {
+			return err
+		}
+		if archived == archive {
+			return errSameStatus
+		}
+		if _, err := tx.ExecContext(r.Context(), fmt.Sprintf("UPDATE %s SET `archived_at` = %s WHERE `id` = ?", table, archivedAt), id); err != nil {
+			return err
+		}
+		return database.RecordAudit(tx, database.EntityBook, id, action, auth.Actor(r.Context()), nil)
+	})
+	switch {
+	case err == sql.ErrNoRows:
+		respond.Error(w, "Book not found", http.StatusNotFound)
+	case errors.Is(err, errSameStatus) && archive:
+		respond.Error(w, "Book already archived", http.StatusConflict)
+	case errors.Is(err, errSameStatus):
+		respond.Error(w, "Book not archived", http.StatusConflict)
+	case err != nil:
+		respond.Error(w, fmt.Sprintf("Database update failed: %v", err), http.StatusInternalServerError)
+	case archive:
+		respond.JSON(w, r, http.StatusOK, map[string]string{"message": "Book archived successfully"})
+	default:
+		respond.JSON(w, r, http.StatusOK, map[string]string{"message": "Book restored successfully"})
+	}
+}
@@ -0,0 +1,98 @@
+package controllers
+
+import (
+	"database/sql"
+	"fmt"
+	"github.com/gorilla/mux"
+	"golang-api-rest-swagger/Core/Books/database"
+	"golang-api-rest-swagger/Core/Books/models"
+	"golang-api-rest-swagger/Core/Shared/respond"
+	"net/http"
+	"strconv"
+	"strings"
+	"unicode"
+)
+
+// bibtexEscaper escapes the characters with a special meaning in a braced BibTeX value, so every
+// title and author is read literally by BibTeX and the reference managers importing the entry.
+var bibtexEscaper = strings.NewReplacer(
+	`\`, `\textbackslash{}`,
+	"{", `\{`,
+	"}", `\}`,
+	"&", `\&`,
+	"%", `\%`,
+	"$", `\$`,
+	"#", `\#`,
+	"_", `\_`,
+	"~", `\textasciitilde{}`,
+	"^", `\textasciicircum{}`,
+)
+
+// GetBookBibTeX handles exporting a book as a BibTeX entry, for reference managers.
+// @Summary Export a book as BibTeX
+// @Description A book as a BibTeX @book entry with its author, title, year and, when set, ISBN. Characters
+// @Description special to BibTeX are escaped. The citation key is the author's last name followed by the year.
+// @Tags books
+// @Produce application/x-bibtex
+// @Param id path int true "Book ID" example(1)
+// @Success 200 {string} string "BibTeX entry"
+// @Failure 404 {string} string "Book not found"
+// @Router /books/{id}.bib [get]
+func GetBookBibTeX(w http.ResponseWriter, r *http.Request, db *sql.DB) {
+	id, err := strconv.ParseInt(mux.Vars(r)["id"], 10, 64)
+	if err != nil {
+		respond.Error(w, "Invalid book ID", http.StatusBadRequest)
+		return
+	}
+
+	var book models.Book
+	err = db.QueryRowContext(r.Context(), fmt.Sprintf("SELECT `id`, `title`, `author`, `publication_year`, COALESCE(`isbn`, '') FROM %s WHERE `id` = ? AND `deleted_at` IS NULL", database.Table(database.Books)), id).
+		Scan(&book.ID, &book.Title, &book.Author, &book.Year, &book.ISBN)
+	if err == sql.ErrNoRows {
+		respond.Error(w, "Book not found", http.StatusNotFound)
+		return
+	}
+	if err != nil {
+		respond.Error(w, fmt.Sprintf("Database query failed: %v", err), http.StatusInternalServerError)
+		return
+	}
+
+	entry := bibtexEntry(book)
+	w.Header().Set("Content-Type", "application/x-bibtex; charset=utf-8")
+	w.Header().Set("Content-Disposition", fmt.Sprintf(`inline; filename="book-%d.bib"`, book.ID))
+	w.Header().Set("Content-Length", strconv.Itoa(len(entry)))
+	w.Write([]byte(entry))
+}
+
+// bibtexEntry formats a book as a BibTeX @book entry.
+func bibtexEntry(book models.Book) string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "@book{%s,\n", bibtexKey(book))
+	fmt.Fprintf(&b, "  author = {%s},\n", bibtexEscaper.Replace(book.Author))
+	fmt.Fprintf(&b, "  title = {%s},\n", bibtexEscaper.Replace(book.Title))
+	fmt.Fprintf(&b, "  year = {%d},\n", book.Year)
+	if book.ISBN != "" {
+		fmt.Fprintf(&b, "  isbn = {%s},\n", book.ISBN)
+	}
+	b.WriteString("}\n")
+	return b.String()
+}
+
+// bibtexKey returns the citation key of a book: the ASCII letters of the last word of its author,
+// lowercased, followed by its year, e.g. tolkien1937. Authors without such letters get book
+// followed by the id, as keys may not hold spaces, braces or commas.
+func bibtexKey(book models.Book) string {
+	words := strings.Fields(book.Author)
+	var name strings.Builder
+	if len(words) > 0 {
+		for _, c := range words[len(words)-1] {
+			if c < unicode.MaxASCII && unicode.IsLetter(c) {
+				name.WriteRune(unicode.ToLower(c))
+			}
+		}
+	}
+	if name.Len() == 0 {
+		return "book" + strconv.FormatInt(book.ID, 10)
+	}
+	return name.String() + strconv.Itoa(book.Year)
+}
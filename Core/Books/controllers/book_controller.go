@@ -2,213 +2,708 @@ package controllers
 
 import (
 	"database/sql"
-	"encoding/json"
+	"errors"
 	"fmt"
 	"github.com/gorilla/mux"
+	"golang-api-rest-swagger/Core/Books/database"
 	"golang-api-rest-swagger/Core/Books/models" // Import the models package
+	"golang-api-rest-swagger/Core/Shared/auth"
+	"golang-api-rest-swagger/Core/Shared/config"
+	"golang-api-rest-swagger/Core/Shared/debugsql"
+	"golang-api-rest-swagger/Core/Shared/respond"
 	"net/http"
 	"strconv"
+	"time"
 )
 
 // GetBooks handles the retrieval of all books from the database.
 // @Summary Get all books
-// @Description Retrieve a list of all books from the database
+// @Description Retrieve a list of all books from the database. When page or limit is given the list is paginated
+// @Description and the total number of books is returned in the X-Total-Count header. With format=ndjson, or an
+// @Description Accept: application/x-ndjson header, the books are streamed one JSON object per line. With
+// @Description format=marc-json, each book is a models.MARCRecord, a simplified MARC 21 record for library systems.
+// @Description The response carries a weak ETag; sending it back in If-None-Match answers 304 Not Modified
+// @Description while no book matching the request has been added, changed or deleted.
 // @Tags books
 // @Produce json
+// @Produce application/x-ndjson
+// @Param page query int false "Page number, starting at 1" example(1)
+// @Param limit query int false "Number of books per page, at most MAX_PAGE_SIZE" example(20)
+// @Param ids query string false "Comma separated list of book IDs to return" example(1,2,3)
+// @Param starts_with query string false "Title prefix, ignoring case; # for titles not starting with a letter" example(T)
+// @Param author query string false "Comma separated list of authors, or a repeated parameter, to return the books of" example(Tolkien)
+// @Param author_not query string false "Comma separated list of authors, or a repeated parameter, whose books are left out, whatever the match mode" example(Lewis)
+// @Param year_not query string false "Comma separated list of years, or a repeated parameter, whose books are left out, whatever the match mode" example(1999)
+// @Param filter query string false "Space separated field:value terms on title, author and year, e.g. author:Tolkien year:>1950 title:~ring" example(author:Tolkien year:>1950)
+// @Param modified_by query string false "Actor, the subject of an API key or anonymous, who made the latest change of the books to return; admins only" example(alice)
+// @Param status query string false "Status of the books to list: active (default) or archived" Enums(active, archived)
+// @Param match query string false "Whether books must match all the filters (default) or any of them" Enums(all, any)
+// @Param sort query string false "Comma separated fields to sort by, descending when prefixed with -: id, title, author, year, created_at. Defaults to DEFAULT_SORT" example(-created_at)
+// @Param fields query string false "Comma separated fields to return, besides the id, which is always returned: title, author, year, cover_url, notes (admins only), isbn, created_at, updated_at. Only these columns are read from the database" example(title,author)
+// @Param shape query string false "Response shape: an array (default) or an object keyed by book ID" Enums(array, map)
+// @Param format query string false "Response format: a JSON array (default), a newline delimited JSON stream, or a JSON array of MARC records" Enums(json, ndjson, marc-json)
+// @Param If-None-Match header string false "ETag of a previous response, to revalidate it"
 // @Success 200 {array} models.Book
+// @Success 204 "No books matched, when EMPTY_LIST_STATUS=204"
+// @Success 304 "The list is unchanged since the ETag in If-None-Match"
+// @Header 200 {string} ETag "Weak ETag of the list, from the number of matching books and their last change"
+// @Header 200 {integer} X-Total-Count "Total number of books (paginated requests only)"
+// @Header 200 {integer} X-Page-Limit "Effective page size after applying the server maximum (paginated requests only)"
+// @Header 200 {string} Link "URLs of the self, first, last, prev and next pages (paginated requests only)"
+// @Failure 400 {string} string "Invalid pagination or filter parameters"
+// @Failure 403 {string} string "modified_by, or an admin-only field, asked for without the admin API key"
+// @Failure 413 {string} string "Too many results, paginate the request"
 // @Router /books [get]
-func GetBooks(w http.ResponseWriter, r *http.Request, db *sql.DB) { // Add db as parameter
+func GetBooks(w http.ResponseWriter, r *http.Request, db *sql.DB, listing config.Listing) { // Add db as parameter
 	w.Header().Set("Content-Type", "application/json")
 
+	pagination, err := parsePagination(r, listing)
+	if err != nil {
+		respond.Error(w, fmt.Sprintf("Invalid pagination parameters: %v", err), http.StatusBadRequest)
+		return
+	}
+
+	// modified_by reads the audit log, which only admins may see.
+	if r.URL.Query().Has("modified_by") {
+		if principal, _ := auth.FromContext(r.Context()); principal.Role != auth.RoleAdmin {
+			respond.Error(w, "Forbidden: modified_by requires the admin API key", http.StatusForbidden)
+			return
+		}
+	}
+
+	filter, err := parseBookFilter(r)
+	if err != nil {
+		respond.Error(w, fmt.Sprintf("Invalid filter: %v", err), http.StatusBadRequest)
+		return
+	}
+
+	principal, _ := auth.FromContext(r.Context())
+	fields, err := parseProjection(r.URL.Query().Get("fields"), principal.Role)
+	var hidden hiddenFieldError
+	if errors.As(err, &hidden) {
+		respond.Error(w, "Forbidden: "+hidden.Error(), http.StatusForbidden)
+		return
+	}
+	if err != nil {
+		respond.Error(w, fmt.Sprintf("Invalid fields: %v", err), http.StatusBadRequest)
+		return
+	}
+
+	shape := r.URL.Query().Get("shape")
+	if shape != "" && shape != "array" && shape != "map" {
+		respond.Error(w, "Invalid shape: must be array or map", http.StatusBadRequest)
+		return
+	}
+
+	ndjson, err := wantsNDJSON(r)
+	if err != nil {
+		respond.Error(w, fmt.Sprintf("Invalid format: %v", err), http.StatusBadRequest)
+		return
+	}
+	if ndjson && shape == "map" {
+		respond.Error(w, "Invalid shape: the map shape is not available for ndjson streams", http.StatusBadRequest)
+		return
+	}
+
+	// MARC records hold whole books, in an array like the catalogs of library systems.
+	render := fields.render
+	marc := r.URL.Query().Get("format") == formatMARC
+	if marc && (fields != nil || shape == "map") {
+		respond.Error(w, "Invalid format: marc-json lists every field of the books as an array, without fields or shape=map", http.StatusBadRequest)
+		return
+	}
+	if marc {
+		render = renderMARC
+	}
+
+	order, err := bookOrder(r.URL.Query().Get("sort"))
+	if err != nil {
+		respond.Error(w, fmt.Sprintf("Invalid sort: %v", err), http.StatusBadRequest)
+		return
+	}
+
+	table := database.Table(database.Books)
+	// Streams are not held in memory, so they are exempt from the unpaginated results limit.
+	guardUnpaginated := listing.MaxUnpaginatedResults > 0 && !ndjson
+	// Count the matching books, so clients can compute the number of pages, and find their last
+	// change, which with the count makes the ETag of the list.
+	var total int
+	var lastUpdate string
+	countQuery := fmt.Sprintf("SELECT COUNT(*), COALESCE(UNIX_TIMESTAMP(MAX(`updated_at`)), 0) FROM %s%s", table, filter.where())
+	debugsql.Record(r.Context(), countQuery, filter.arguments()...)
+	if err := db.QueryRowContext(r.Context(), countQuery, filter.arguments()...).Scan(&total, &lastUpdate); err != nil {
+		respond.Error(w, fmt.Sprintf("Database query failed: %v", err), http.StatusInternalServerError)
+		return
+	}
+
+	// Number of books the query returns.
+	expected := total
+	// Deep pages seek to their first book rather than skip every previous one, when the list allows it.
+	deep := false
+	if pagination != nil {
+		w.Header().Set("X-Total-Count", strconv.Itoa(total))
+		w.Header().Set("X-Page-Limit", strconv.Itoa(pagination.Limit))
+		setPaginationLinks(w, r, pagination, total)
+		expected = max(0, min(pagination.Limit, total-pagination.Offset()))
+		deep = listing.SeekOffset > 0 && pagination.Offset() >= listing.SeekOffset && expected > 0
+	} else if guardUnpaginated && total > listing.MaxUnpaginatedResults {
+		// Refuse to return an unbounded list instead of loading every row in memory.
+		respond.Error(w, fmt.Sprintf("Too many results (%d, maximum %d without pagination): use the page and limit parameters to paginate", total, listing.MaxUnpaginatedResults), http.StatusRequestEntityTooLarge)
+		return
+	}
+	query, args, err := listQuery(fields, table, filter, order, pagination, deep, seekBoundary(r.Context(), db))
+	if err != nil {
+		respond.Error(w, fmt.Sprintf("Database query failed: %v", err), http.StatusInternalServerError)
+		return
+	}
+
+	// Let clients revalidate the list without downloading it again.
+	etag := listETag(total, lastUpdate, order)
+	w.Header().Set("ETag", etag)
+	w.Header().Set("Cache-Control", "no-cache")
+	// The ndjson stream can be asked for with the Accept header, under the same URL and ETag.
+	w.Header().Add("Vary", "Accept")
+	// The notes are only listed for admins, so the body depends on the API key too.
+	w.Header().Add("Vary", auth.APIKeyHeader)
+	if etagMatches(r.Header.Get("If-None-Match"), etag) {
+		w.WriteHeader(http.StatusNotModified)
+		return
+	}
+
 	// Query the database.
-	rows, err := db.Query("SELECT id, title, author, YEAR FROM books")
+	debugsql.Record(r.Context(), query, args...)
+	rows, err := db.QueryContext(r.Context(), query, args...)
 	if err != nil {
-		http.Error(w, fmt.Sprintf("Database query failed: %v", err), http.StatusInternalServerError)
+		respond.Error(w, fmt.Sprintf("Database query failed: %v", err), http.StatusInternalServerError)
 		return
 	}
 	defer rows.Close()
 
+	if ndjson {
+		streamBooks(w, r, rows, fields, render)
+		return
+	}
+
+	// Large lists are streamed as they are read; small ones are buffered so the response has a
+	// Content-Length. The map shape and the empty list status need every book, so they are always buffered.
+	if shape != "map" && !listing.EmptyNoContent && shouldStream(listing, expected) {
+		streamBookArray(w, r, rows, fields, render)
+		return
+	}
+
 	// Create a slice to hold the results.
 	books := []models.Book{} // Use models.Book
 
 	// Iterate over the rows.
 	for rows.Next() {
-		var book models.Book // Use models.Book
-		if err := rows.Scan(&book.ID, &book.Title, &book.Author, &book.Year); err != nil {
-			http.Error(w, fmt.Sprintf("Failed to scan row: %v", err), http.StatusInternalServerError)
+		book, err := fields.scan(rows)
+		if err != nil {
+			respond.Error(w, fmt.Sprintf("Failed to scan row: %v", err), http.StatusInternalServerError)
 			return
 		}
 		books = append(books, book)
 	}
 
 	if err := rows.Err(); err != nil {
-		http.Error(w, fmt.Sprintf("Error during row iteration: %v", err), http.StatusInternalServerError)
+		respond.Error(w, fmt.Sprintf("Error during row iteration: %v", err), http.StatusInternalServerError)
+		return
+	}
+
+	// Some clients prefer no body at all to an empty list; this applies to filtered and paginated
+	// requests alike, including pages past the last one. X-Total-Count is still sent.
+	if len(books) == 0 && listing.EmptyNoContent {
+		w.WriteHeader(http.StatusNoContent)
+		return
+	}
+
+	if marc {
+		respond.JSON(w, r, http.StatusOK, marcRecords(books))
 		return
 	}
 
-	json.NewEncoder(w).Encode(books)
+	// Key the books by id when the client wants to look them up directly, and keep only the
+	// fields asked for.
+	body, err := fields.list(books, shape == "map")
+	if err != nil {
+		respond.Error(w, "Failed to encode response", http.StatusInternalServerError)
+		return
+	}
+	respond.JSON(w, r, http.StatusOK, body)
 }
 
 // GetBook handles the retrieval of a single book by ID from the database.
 // @Summary Get a book by ID
-// @Description Retrieve a single book by its ID from the database
+// @Description Retrieve a single book by its ID from the database. With format=marc-json, the book is a
+// @Description models.MARCRecord, a simplified MARC 21 record for library systems.
 // @Tags books
 // @Produce json
-// @Param id path int true "Book ID"
+// @Param id path int true "Book ID" example(1)
+// @Param format query string false "Response format: the book (default) or its MARC record" Enums(json, marc-json)
 // @Success 200 {object} models.Book
+// @Failure 400 {string} string "Invalid book ID or format"
 // @Failure 404 {string} string "Book not found"
 // @Router /books/{id} [get]
 func GetBook(w http.ResponseWriter, r *http.Request, db *sql.DB) { // Add db as parameter
 	w.Header().Set("Content-Type", "application/json")
 	params := mux.Vars(r)
-	id, err := strconv.Atoi(params["id"])
+	id, err := strconv.ParseInt(params["id"], 10, 64)
+	if err != nil {
+		respond.Error(w, "Invalid book ID", http.StatusBadRequest)
+		return
+	}
+	marc, err := wantsMARC(r)
 	if err != nil {
-		http.Error(w, "Invalid book ID", http.StatusBadRequest)
+		respond.Error(w, fmt.Sprintf("Invalid format: %v", err), http.StatusBadRequest)
 		return
 	}
 
 	// Query the database for the book with the given ID.
-	row := db.QueryRow("SELECT id, title, author, YEAR FROM books WHERE id = ?", id)
+	row := db.QueryRowContext(r.Context(), fmt.Sprintf("SELECT %s FROM %s WHERE `id` = ? AND `deleted_at` IS NULL", projection(nil).selectList(), database.Table(database.Books)), id)
 	var book models.Book // Use models.Book
-	err = row.Scan(&book.ID, &book.Title, &book.Author, &book.Year)
+	err = row.Scan(projection(nil).dests(&book)...)
 	if err != nil {
 		if err == sql.ErrNoRows {
-			http.Error(w, "Book not found", http.StatusNotFound)
+			respond.Error(w, "Book not found", http.StatusNotFound)
 			return
 		}
-		http.Error(w, fmt.Sprintf("Database query failed: %v", err), http.StatusInternalServerError)
+		respond.Error(w, fmt.Sprintf("Database query failed: %v", err), http.StatusInternalServerError)
 		return
 	}
 
-	json.NewEncoder(w).Encode(book)
+	if marc {
+		respond.JSON(w, r, http.StatusOK, models.NewMARCRecord(book))
+		return
+	}
+	respond.JSON(w, r, http.StatusOK, book)
+}
+
+// BookExists handles checking that a book exists without fetching it.
+// @Summary Check that a book exists
+// @Description Answer 204 when the book exists and 404 otherwise, without a body
+// @Tags books
+// @Param id path int true "Book ID" example(1)
+// @Success 204 "Book exists"
+// @Failure 400 {string} string "Invalid book ID"
+// @Failure 404 {string} string "Book not found"
+// @Router /books/{id}/exists [get]
+func BookExists(w http.ResponseWriter, r *http.Request, db *sql.DB) {
+	id, err := strconv.ParseInt(mux.Vars(r)["id"], 10, 64)
+	if err != nil {
+		respond.Error(w, "Invalid book ID", http.StatusBadRequest)
+		return
+	}
+
+	var exists int
+	err = db.QueryRowContext(r.Context(), fmt.Sprintf("SELECT 1 FROM %s WHERE `id` = ? AND `deleted_at` IS NULL", database.Table(database.Books)), id).Scan(&exists)
+	switch {
+	case err == sql.ErrNoRows:
+		respond.Error(w, "Book not found", http.StatusNotFound)
+	case err != nil:
+		respond.Error(w, fmt.Sprintf("Database query failed: %v", err), http.StatusInternalServerError)
+	default:
+		w.WriteHeader(http.StatusNoContent)
+	}
+}
+
+// GetBookSchema handles the retrieval of the Book field descriptions.
+// @Summary Get the book schema
+// @Description Describe the fields of a book (name, type, required, max length), derived from the model's validation rules
+// @Tags books
+// @Produce json
+// @Success 200 {object} models.Schema
+// @Router /books/schema [get]
+func GetBookSchema(w http.ResponseWriter, r *http.Request) {
+	respond.JSON(w, r, http.StatusOK, models.Describe("book", models.Book{}))
 }
 
 // CreateBook handles the creation of a new book in the database.
 // @Summary Create a new book
-// @Description Add a new book to the database
+// @Description Add a new book to the database. The year may be omitted and defaults to the current year.
+// @Description With Prefer: return=minimal the response has no body, only the Location of the new book.
 // @Tags books
 // @Accept json
 // @Produce json
-// @Param book body models.Book true "Book object to be added"
+// @Param book body models.BookInput true "Book object to be added"
+// @Param Prefer header string false "return=minimal to omit the created book from the response" example(return=minimal)
 // @Success 201 {object} models.Book
+// @Header 201 {string} Location "URL of the new book"
 // @Failure 400 {string} string "Invalid request body"
+// @Failure 415 {string} string "Request body not sent as application/json"
+// @Failure 409 {string} string "Another book already has this ISBN, or this title and author when ENFORCE_UNIQUE_TITLE_AUTHOR is enabled"
 // @Router /books [post]
 func CreateBook(w http.ResponseWriter, r *http.Request, db *sql.DB) { // Add db as parameter
 	w.Header().Set("Content-Type", "application/json")
 	var book models.Book // Use models.Book
-	if err := json.NewDecoder(r.Body).Decode(&book); err != nil {
-		http.Error(w, fmt.Sprintf("Invalid request body: %v", err), http.StatusBadRequest)
+	if err := decodeJSON(r, &book); err != nil {
+		respond.Error(w, err.Error(), http.StatusBadRequest)
 		return
 	}
-	if book.Title == "" || book.Author == "" || book.Year == 0 {
-		http.Error(w, "Invalid request body: Title, Author, and Year are required", http.StatusBadRequest)
+	keepHiddenFields(r, &book, models.Book{})
+	if errs := validateNewBook("CreateBook", &book, time.Now()); errs != nil {
+		respond.Error(w, "Invalid request body: "+errs.Error(), http.StatusBadRequest)
 		return
 	}
 
-	// Insert the new book into the database.
-	result, err := db.Exec("INSERT INTO books (title, author, year) VALUES (?, ?, ?)", book.Title, book.Author, book.Year)
+	// Insert the new book and record it in the audit log in one transaction.
+	err := database.WithTx(r.Context(), db, func(tx *sql.Tx) error {
+		result, err := tx.ExecContext(r.Context(), fmt.Sprintf("INSERT INTO %s (`title`, `author`, `publication_year`, `cover_url`, `notes`, `isbn`) VALUES (?, ?, ?, ?, ?, NULLIF(?, ''))", database.Table(database.Books)), book.Title, book.Author, book.Year, book.CoverURL, book.Notes, book.ISBN)
+		if err != nil {
+			return err
+		}
+
+		// Get the ID of the newly inserted book.
+		insertID, err := result.LastInsertId()
+		if err != nil {
+			return fmt.Errorf("failed to get last insert ID: %w", err)
+		}
+		book.ID = insertID
+
+		return database.RecordAudit(tx, database.EntityBook, book.ID, database.ActionCreate, auth.Actor(r.Context()), book)
+	})
+	if message, conflict := conflictMessage(err); conflict {
+		respond.Error(w, message, http.StatusConflict)
+		return
+	}
 	if err != nil {
-		http.Error(w, fmt.Sprintf("Database insert failed: %v", err), http.StatusInternalServerError)
+		respond.Error(w, fmt.Sprintf("Database insert failed: %v", err), http.StatusInternalServerError)
 		return
 	}
 
-	// Get the ID of the newly inserted book.
-	insertID, err := result.LastInsertId()
-	if err != nil {
-		http.Error(w, fmt.Sprintf("Failed to get last insert ID: %v", err), http.StatusInternalServerError)
+	w.Header().Set("Location", fmt.Sprintf("/books/%d", book.ID))
+	if prefersMinimal(r) {
+		w.Header().Del("Content-Type")
+		w.Header().Set("Preference-Applied", "return=minimal")
+		w.WriteHeader(http.StatusCreated)
 		return
 	}
-	book.ID = int(insertID)
+	respond.JSON(w, r, http.StatusCreated, book)
+}
 
-	w.WriteHeader(http.StatusCreated)
-	json.NewEncoder(w).Encode(book)
+// BookDiff is the response of UpdateBook when the diff is requested.
+// Before is null when the request created the book.
+type BookDiff struct {
+	Before *models.Book `json:"before"`
+	After  models.Book  `json:"after"`
+}
+
+// errBookDeleted reports that a book exists but has been soft deleted.
+var errBookDeleted = errors.New("book deleted")
+
+// conflictMessage returns the 409 answer to a write that failed because it would give a book the
+// ISBN, or the title and author when they are unique, of another one; soft deleted books keep
+// theirs. conflict is false for other errors.
+func conflictMessage(err error) (message string, conflict bool) {
+	switch database.DuplicateKey(err) {
+	case "":
+		return "", false
+	case database.UniqueTitleAuthor:
+		return "Another book already has this title and author", true
+	default:
+		return "Another book already has this ISBN", true
+	}
 }
 
 // UpdateBook handles the updating of an existing book in the database.
+// When upsert is enabled (PUT_UPSERT), a PUT to an unknown id creates the book with that id instead of failing.
 // @Summary Update an existing book
-// @Description Update the details of an existing book in the database
+// @Description Update the details of an existing book in the database. With include=diff the response holds
+// @Description the book before and after the update. When the server enables PUT_UPSERT, an unknown id creates
+// @Description the book with that id and returns 201 Created with a Location header.
 // @Tags books
 // @Accept json
 // @Produce json
-// @Param id path int true "Book ID"
-// @Param book body models.Book true "Updated book object"
+// @Param id path int true "Book ID" example(1)
+// @Param include query string false "Set to diff to return the book before and after the update" Enums(diff)
+// @Param book body models.BookInput true "Updated book object"
 // @Success 200 {object} models.Book
+// @Success 201 {object} models.Book "Created, when PUT_UPSERT is enabled"
+// @Header 201 {string} Location "URL of the created book"
 // @Failure 400 {string} string "Invalid request body"
+// @Failure 415 {string} string "Request body not sent as application/json"
 // @Failure 404 {string} string "Book not found"
+// @Failure 410 {string} string "Book already deleted (PUT_UPSERT only)"
+// @Failure 409 {string} string "Another book already has this ISBN, or this title and author when ENFORCE_UNIQUE_TITLE_AUTHOR is enabled"
 // @Router /books/{id} [put]
-func UpdateBook(w http.ResponseWriter, r *http.Request, db *sql.DB) { // Add db as parameter
+func UpdateBook(w http.ResponseWriter, r *http.Request, db *sql.DB, upsert bool) { // Add db as parameter
 	w.Header().Set("Content-Type", "application/json")
 	params := mux.Vars(r)
-	id, err := strconv.Atoi(params["id"])
+	id, err := strconv.ParseInt(params["id"], 10, 64)
 	if err != nil {
-		http.Error(w, "Invalid book ID", http.StatusBadRequest)
+		respond.Error(w, "Invalid book ID", http.StatusBadRequest)
 		return
 	}
 
 	var updatedBook models.Book // Use models.Book
-	if err := json.NewDecoder(r.Body).Decode(&updatedBook); err != nil {
-		http.Error(w, fmt.Sprintf("Invalid request body: %v", err), http.StatusBadRequest)
+	if err := decodeJSON(r, &updatedBook); err != nil {
+		respond.Error(w, err.Error(), http.StatusBadRequest)
 		return
 	}
-	if updatedBook.Title == "" || updatedBook.Author == "" || updatedBook.Year == 0 {
-		http.Error(w, "Invalid request body: Title, Author, and Year are required", http.StatusBadRequest)
+	if errs := validateBook("UpdateBook", updatedBook); errs != nil {
+		respond.Error(w, "Invalid request body: "+errs.Error(), http.StatusBadRequest)
 		return
 	}
+	updatedBook.ID = id
 
-	// Update the book in the database.
-	result, err := db.Exec("UPDATE books SET title = ?, author = ?, year = ? WHERE id = ?", updatedBook.Title, updatedBook.Author, updatedBook.Year, id)
+	// Read the current row and write the new one in one transaction, so the previous state is exactly
+	// the one that was overwritten, and the create-or-update decision cannot race another request.
+	table := database.Table(database.Books)
+	var before *models.Book
+	err = database.WithTx(r.Context(), db, func(tx *sql.Tx) error {
+		// Reset on every attempt: a retried transaction may take the other branch.
+		before = nil
+		var current models.Book
+		var deleted bool
+		err := tx.QueryRowContext(r.Context(), fmt.Sprintf("SELECT `id`, `title`, `author`, `publication_year`, `cover_url`, `notes`, COALESCE(`isbn`, ''), `deleted_at` IS NOT NULL FROM %s WHERE `id` = ? FOR UPDATE", table), id).
+			Scan(&current.ID, &current.Title, &current.Author, &current.Year, &current.CoverURL, &current.Notes, &current.ISBN, &deleted)
+		switch {
+		case err == sql.ErrNoRows && upsert:
+			keepHiddenFields(r, &updatedBook, models.Book{ID: id})
+			_, err = tx.ExecContext(r.Context(), fmt.Sprintf("INSERT INTO %s (`id`, `title`, `author`, `publication_year`, `cover_url`, `notes`, `isbn`) VALUES (?, ?, ?, ?, ?, ?, NULLIF(?, ''))", table), id, updatedBook.Title, updatedBook.Author, updatedBook.Year, updatedBook.CoverURL, updatedBook.Notes, updatedBook.ISBN)
+			if err != nil {
+				return err
+			}
+			return database.RecordAudit(tx, database.EntityBook, id, database.ActionCreate, auth.Actor(r.Context()), updatedBook)
+		case err != nil:
+			return err
+		case deleted && upsert:
+			return errBookDeleted
+		case deleted:
+			return sql.ErrNoRows
+		}
+
+		before = &current
+		keepHiddenFields(r, &updatedBook, current)
+		_, err = tx.ExecContext(r.Context(), fmt.Sprintf("UPDATE %s SET `title` = ?, `author` = ?, `publication_year` = ?, `cover_url` = ?, `notes` = ?, `isbn` = NULLIF(?, '') WHERE `id` = ?", table), updatedBook.Title, updatedBook.Author, updatedBook.Year, updatedBook.CoverURL, updatedBook.Notes, updatedBook.ISBN, id)
+		if err != nil {
+			return err
+		}
+		return database.RecordAudit(tx, database.EntityBook, id, database.ActionUpdate, auth.Actor(r.Context()), BookDiff{Before: before, After: updatedBook})
+	})
 	if err != nil {
-		http.Error(w, fmt.Sprintf("Database update failed: %v", err), http.StatusInternalServerError)
+		switch err {
+		case sql.ErrNoRows:
+			respond.Error(w, "Book not found", http.StatusNotFound)
+		case errBookDeleted:
+			respond.Error(w, "Book already deleted", http.StatusGone)
+		default:
+			if message, conflict := conflictMessage(err); conflict {
+				respond.Error(w, message, http.StatusConflict)
+				return
+			}
+			respond.Error(w, fmt.Sprintf("Database update failed: %v", err), http.StatusInternalServerError)
+		}
 		return
 	}
-	rowsAffected, err := result.RowsAffected()
+
+	status := http.StatusOK
+	if before == nil {
+		status = http.StatusCreated
+		w.Header().Set("Location", fmt.Sprintf("/books/%d", id))
+	}
+	if r.URL.Query().Get("include") == "diff" {
+		respond.JSON(w, r, status, BookDiff{Before: before, After: updatedBook})
+		return
+	}
+	respond.JSON(w, r, status, updatedBook)
+}
+
+// YearUpdate is the request body of UpdateBookYear.
+type YearUpdate struct {
+	Year int `json:"year" example:"1999"`
+}
+
+// UpdateBookYear handles updating only the publication year of an existing book.
+// @Summary Update the year of a book
+// @Description Update only the publication year of an existing book
+// @Tags books
+// @Accept json
+// @Produce json
+// @Param id path int true "Book ID" example(1)
+// @Param year body YearUpdate true "New publication year"
+// @Success 200 {object} models.Book
+// @Failure 400 {string} string "Invalid request body"
+// @Failure 415 {string} string "Request body not sent as application/json"
+// @Failure 404 {string} string "Book not found"
+// @Router /books/{id}/year [patch]
+func UpdateBookYear(w http.ResponseWriter, r *http.Request, db *sql.DB) {
+	w.Header().Set("Content-Type", "application/json")
+	params := mux.Vars(r)
+	id, err := strconv.ParseInt(params["id"], 10, 64)
 	if err != nil {
-		http.Error(w, fmt.Sprintf("Failed to get number of updated rows: %v", err), http.StatusInternalServerError)
+		respond.Error(w, "Invalid book ID", http.StatusBadRequest)
 		return
 	}
 
-	if rowsAffected == 0 {
-		http.Error(w, "Book not found", http.StatusNotFound)
+	var update YearUpdate
+	if err := decodeJSON(r, &update); err != nil {
+		respond.Error(w, err.Error(), http.StatusBadRequest)
 		return
 	}
-	updatedBook.ID = id
-	json.NewEncoder(w).Encode(updatedBook)
+	if update.Year == 0 {
+		respond.Error(w, "Invalid request body: Year is required", http.StatusBadRequest)
+		return
+	}
+
+	// Lock the book, update only the publication_year column and record the change in one transaction.
+	table := database.Table(database.Books)
+	var before models.Book
+	err = database.WithTx(r.Context(), db, func(tx *sql.Tx) error {
+		err := tx.QueryRowContext(r.Context(), fmt.Sprintf("SELECT `id`, `title`, `author`, `publication_year`, `cover_url`, `notes`, COALESCE(`isbn`, '') FROM %s WHERE `id` = ? AND `deleted_at` IS NULL FOR UPDATE", table), id).
+			Scan(&before.ID, &before.Title, &before.Author, &before.Year, &before.CoverURL, &before.Notes, &before.ISBN)
+		if err != nil {
+			return err
+		}
+		if _, err := tx.ExecContext(r.Context(), fmt.Sprintf("UPDATE %s SET `publication_year` = ? WHERE `id` = ?", table), update.Year, id); err != nil {
+			return err
+		}
+		after := before
+		after.Year = update.Year
+		return database.RecordAudit(tx, database.EntityBook, id, database.ActionUpdate, auth.Actor(r.Context()), BookDiff{Before: &before, After: after})
+	})
+	if err != nil {
+		if err == sql.ErrNoRows {
+			respond.Error(w, "Book not found", http.StatusNotFound)
+			return
+		}
+		respond.Error(w, fmt.Sprintf("Database update failed: %v", err), http.StatusInternalServerError)
+		return
+	}
+
+	book := before
+	book.Year = update.Year
+	respond.JSON(w, r, http.StatusOK, book)
+}
+
+// PatchBook handles the partial update of an existing book. The body follows the JSON Merge Patch
+// rules of RFC 7386 whether it is sent as application/json or application/merge-patch+json.
+// @Summary Partially update a book
+// @Description Update only the fields present in the body. Absent fields are left unchanged, null clears an
+// @Description optional field such as cover_url, and null for a required field is rejected. The body is a
+// @Description JSON Merge Patch (RFC 7386) and may be sent as application/merge-patch+json.
+// @Tags books
+// @Accept json,application/merge-patch+json
+// @Produce json
+// @Param id path int true "Book ID" example(1)
+// @Param patch body models.BookPatch true "Fields to change"
+// @Success 200 {object} models.Book
+// @Failure 400 {string} string "Invalid request body"
+// @Failure 415 {string} string "Request body not sent as application/json or application/merge-patch+json"
+// @Failure 404 {string} string "Book not found"
+// @Failure 409 {string} string "Another book already has this ISBN, or this title and author when ENFORCE_UNIQUE_TITLE_AUTHOR is enabled"
+// @Router /books/{id} [patch]
+func PatchBook(w http.ResponseWriter, r *http.Request, db *sql.DB) {
+	w.Header().Set("Content-Type", "application/json")
+	params := mux.Vars(r)
+	id, err := strconv.ParseInt(params["id"], 10, 64)
+	if err != nil {
+		respond.Error(w, "Invalid book ID", http.StatusBadRequest)
+		return
+	}
+
+	var patch models.BookPatch
+	if err := decodeJSON(r, &patch); err != nil {
+		respond.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	// The patch is applied to the locked current row, so concurrent patches of different fields
+	// do not overwrite each other.
+	table := database.Table(database.Books)
+	var after models.Book
+	err = database.WithTx(r.Context(), db, func(tx *sql.Tx) error {
+		var before models.Book
+		err := tx.QueryRowContext(r.Context(), fmt.Sprintf("SELECT `id`, `title`, `author`, `publication_year`, `cover_url`, `notes`, COALESCE(`isbn`, '') FROM %s WHERE `id` = ? AND `deleted_at` IS NULL FOR UPDATE", table), id).
+			Scan(&before.ID, &before.Title, &before.Author, &before.Year, &before.CoverURL, &before.Notes, &before.ISBN)
+		if err != nil {
+			return err
+		}
+		var errs models.FieldErrors
+		if after, errs = patch.Apply(before); errs != nil {
+			countValidationFailures("PatchBook", errs)
+			return errs
+		}
+		keepHiddenFields(r, &after, before)
+		if errs := validateBook("PatchBook", after); errs != nil {
+			return errs
+		}
+		_, err = tx.ExecContext(r.Context(), fmt.Sprintf("UPDATE %s SET `title` = ?, `author` = ?, `publication_year` = ?, `cover_url` = ?, `notes` = ?, `isbn` = NULLIF(?, '') WHERE `id` = ?", table), after.Title, after.Author, after.Year, after.CoverURL, after.Notes, after.ISBN, id)
+		if err != nil {
+			return err
+		}
+		return database.RecordAudit(tx, database.EntityBook, id, database.ActionUpdate, auth.Actor(r.Context()), BookDiff{Before: &before, After: after})
+	})
+	var errs models.FieldErrors
+	switch {
+	case err == sql.ErrNoRows:
+		respond.Error(w, "Book not found", http.StatusNotFound)
+	case errors.As(err, &errs):
+		respond.Error(w, "Invalid request body: "+errs.Error(), http.StatusBadRequest)
+	case database.DuplicateKey(err) != "":
+		message, _ := conflictMessage(err)
+		respond.Error(w, message, http.StatusConflict)
+	case err != nil:
+		respond.Error(w, fmt.Sprintf("Database update failed: %v", err), http.StatusInternalServerError)
+	default:
+		respond.JSON(w, r, http.StatusOK, after)
+	}
 }
 
 // DeleteBook handles the deletion of a book from the database.
+// Books are soft deleted: the row is kept with deleted_at set and hidden from every other endpoint.
 // @Summary Delete a book
-// @Description Delete a book from the database
+// @Description Soft delete a book. Deleting a book that was already deleted returns 410 Gone
 // @Tags books
 // @Produce json
-// @Param id path int true "Book ID"
+// @Param id path int true "Book ID" example(1)
 // @Success 200 {string} string "Book deleted successfully"
 // @Failure 404 {string} string "Book not found"
+// @Failure 410 {string} string "Book already deleted"
 // @Router /books/{id} [delete]
 func DeleteBook(w http.ResponseWriter, r *http.Request, db *sql.DB) { // Add db as parameter.
 	w.Header().Set("Content-Type", "application/json")
 	params := mux.Vars(r)
-	id, err := strconv.Atoi(params["id"])
+	id, err := strconv.ParseInt(params["id"], 10, 64)
 	if err != nil {
-		http.Error(w, "Invalid book ID", http.StatusBadRequest)
+		respond.Error(w, "Invalid book ID", http.StatusBadRequest)
 		return
 	}
 
-	// Delete the book from the database.
-	result, err := db.Exec("DELETE FROM books WHERE id = ?", id)
-	if err != nil {
-		http.Error(w, fmt.Sprintf("Database delete failed: %v", err), http.StatusInternalServerError)
-		return
-	}
-	rowsAffected, err := result.RowsAffected()
+	// Soft delete the book and record it in the audit log in one transaction.
+	table := database.Table(database.Books)
+	var rowsAffected int64
+	err = database.WithTx(r.Context(), db, func(tx *sql.Tx) error {
+		result, err := tx.ExecContext(r.Context(), fmt.Sprintf("UPDATE %s SET `deleted_at` = CURRENT_TIMESTAMP WHERE `id` = ? AND `deleted_at` IS NULL", table), id)
+		if err != nil {
+			return err
+		}
+		if rowsAffected, err = result.RowsAffected(); err != nil {
+			return fmt.Errorf("failed to get number of deleted rows: %w", err)
+		}
+		if rowsAffected == 0 {
+			return nil
+		}
+		return database.RecordAudit(tx, database.EntityBook, id, database.ActionDelete, auth.Actor(r.Context()), nil)
+	})
 	if err != nil {
-		http.Error(w, fmt.Sprintf("Failed to get number of deleted rows: %v", err), http.StatusInternalServerError)
+		respond.Error(w, fmt.Sprintf("Database delete failed: %v", err), http.StatusInternalServerError)
 		return
 	}
 	if rowsAffected == 0 {
-		http.Error(w, "Book not found", http.StatusNotFound)
+		// Nothing was deleted: tell apart a book that never existed from one deleted earlier.
+		var deleted bool
+		err := db.QueryRowContext(r.Context(), fmt.Sprintf("SELECT `deleted_at` IS NOT NULL FROM %s WHERE `id` = ?", table), id).Scan(&deleted)
+		switch {
+		case err == sql.ErrNoRows:
+			respond.Error(w, "Book not found", http.StatusNotFound)
+		case err != nil:
+			respond.Error(w, fmt.Sprintf("Database query failed: %v", err), http.StatusInternalServerError)
+		case deleted:
+			respond.Error(w, "Book already deleted", http.StatusGone)
+		default:
+			respond.Error(w, "Book not found", http.StatusNotFound)
+		}
 		return
 	}
 
-	w.WriteHeader(http.StatusOK)
-	json.NewEncoder(w).Encode(map[string]string{"message": "Book deleted successfully"})
+	respond.JSON(w, r, http.StatusOK, map[string]string{"message": "Book deleted successfully"})
 }
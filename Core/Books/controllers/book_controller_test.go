@@ -0,0 +1,101 @@
+package controllers
+
+import (
+	"database/sql"
+	"database/sql/driver"
+	"encoding/json"
+	"errors"
+	"github.com/gorilla/mux"
+	"golang-api-rest-swagger/Core/Books/models"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+// serve runs handler on a request to target with the given route variables and body.
+func serve(handler http.HandlerFunc, method, target string, vars map[string]string, body string) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(method, target, strings.NewReader(body))
+	if body != "" {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	if vars != nil {
+		req = mux.SetURLVars(req, vars)
+	}
+	rec := httptest.NewRecorder()
+	handler(rec, req)
+	return rec
+}
+
+func TestBookHandlersRejectInvalidIDs(t *testing.T) {
+	// A nil database: the id is checked before any query.
+	var db *sql.DB
+	handlers := map[string]http.HandlerFunc{
+		"GetBook":        func(w http.ResponseWriter, r *http.Request) { GetBook(w, r, db) },
+		"BookExists":     func(w http.ResponseWriter, r *http.Request) { BookExists(w, r, db) },
+		"UpdateBook":     func(w http.ResponseWriter, r *http.Request) { UpdateBook(w, r, db, false) },
+		"UpdateBookYear": func(w http.ResponseWriter, r *http.Request) { UpdateBookYear(w, r, db) },
+		"PatchBook":      func(w http.ResponseWriter, r *http.Request) { PatchBook(w, r, db) },
+		"DeleteBook":     func(w http.ResponseWriter, r *http.Request) { DeleteBook(w, r, db) },
+		"ArchiveBook":    func(w http.ResponseWriter, r *http.Request) { ArchiveBook(w, r, db) },
+		"RestoreBook":    func(w http.ResponseWriter, r *http.Request) { RestoreBook(w, r, db) },
+		"CloneBook":      func(w http.ResponseWriter, r *http.Request) { CloneBook(w, r, db) },
+		"GetBookBibTeX":  func(w http.ResponseWriter, r *http.Request) { GetBookBibTeX(w, r, db) },
+	}
+	// The ids are int64: one past the largest overflows.
+	ids := []string{"abc", "1.5", "", "-", "9223372036854775808", "99999999999999999999"}
+	for name, handler := range handlers {
+		for _, id := range ids {
+			rec := serve(handler, "GET", "/books/"+id, map[string]string{"id": id}, `{"title":"Dune","author":"Frank Herbert","year":1965}`)
+			if rec.Code != http.StatusBadRequest || !strings.Contains(rec.Body.String(), "Invalid book ID") {
+				t.Errorf("%s with id %q = %d %q, want 400 Invalid book ID", name, id, rec.Code, rec.Body)
+			}
+		}
+	}
+}
+
+func TestGetBook(t *testing.T) {
+	created := time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+	updated := time.Date(2024, 5, 2, 8, 30, 0, 0, time.UTC)
+	columns := []string{"id", "title", "author", "publication_year", "cover_url", "notes", "isbn", "created_at", "updated_at"}
+
+	t.Run("found", func(t *testing.T) {
+		db, queries := openFakeDB(t, fakeResult{columns: columns, rows: [][]driver.Value{
+			{int64(7), "Dune", "Frank Herbert", int64(1965), "", "", "9780441013593", created, updated},
+		}})
+		rec := serve(func(w http.ResponseWriter, r *http.Request) { GetBook(w, r, db) }, "GET", "/books/7", map[string]string{"id": "7"}, "")
+		if rec.Code != http.StatusOK {
+			t.Fatalf("status = %d, want 200: %s", rec.Code, rec.Body)
+		}
+		var book models.Book
+		if err := json.NewDecoder(rec.Body).Decode(&book); err != nil {
+			t.Fatal(err)
+		}
+		if book.ID != 7 || book.Title != "Dune" || book.Year != 1965 || book.ISBN != "9780441013593" {
+			t.Errorf("book = %+v, want the stored one", book)
+		}
+		if book.CreatedAt == nil || !book.CreatedAt.Equal(created) || book.UpdatedAt == nil || !book.UpdatedAt.Equal(updated) {
+			t.Errorf("timestamps = %v, %v, want %v, %v", book.CreatedAt, book.UpdatedAt, created, updated)
+		}
+		if got := queries(); len(got) != 1 || !strings.Contains(got[0], "WHERE `id` = ? AND `deleted_at` IS NULL") {
+			t.Errorf("queries = %q, want one read of the book unless deleted", got)
+		}
+	})
+
+	t.Run("not found", func(t *testing.T) {
+		db, _ := openFakeDB(t, fakeResult{columns: columns})
+		rec := serve(func(w http.ResponseWriter, r *http.Request) { GetBook(w, r, db) }, "GET", "/books/7", map[string]string{"id": "7"}, "")
+		if rec.Code != http.StatusNotFound {
+			t.Errorf("status = %d, want 404: %s", rec.Code, rec.Body)
+		}
+	})
+
+	t.Run("database error", func(t *testing.T) {
+		db, _ := openFakeDB(t, fakeResult{err: errors.New("connection lost")})
+		rec := serve(func(w http.ResponseWriter, r *http.Request) { GetBook(w, r, db) }, "GET", "/books/7", map[string]string{"id": "7"}, "")
+		if rec.Code != http.StatusInternalServerError {
+			t.Errorf("status = %d, want 500: %s", rec.Code, rec.Body)
+		}
+	})
+}
@@ -0,0 +1,198 @@
+package controllers
+
+import (
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"golang-api-rest-swagger/Core/Books/database"
+	"golang-api-rest-swagger/Core/Books/models"
+	"golang-api-rest-swagger/Core/Shared/auth"
+	"golang-api-rest-swagger/Core/Shared/config"
+	"golang-api-rest-swagger/Core/Shared/respond"
+	"io"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+// BulkLimitError is the response of a bulk request above the configured limits. It states the
+// limits so clients can split the batch accordingly.
+type BulkLimitError struct {
+	Error    string `json:"error" example:"Too many books (1200): at most 1000 per request, split the batch"`
+	MaxItems int    `json:"max_items" example:"1000"`
+	MaxBytes int64  `json:"max_bytes" example:"1048576"`
+}
+
+// Outcomes of a book of a non-atomic bulk create.
+const (
+	bulkCreated = "created"
+	bulkFailed  = "failed"
+)
+
+// BulkItemResult is the outcome of one book of a non-atomic bulk create.
+type BulkItemResult struct {
+	// Index is the position of the book in the request, from 0.
+	Index  int          `json:"index" example:"0"`
+	Status string       `json:"status" enums:"created,failed" example:"created"`
+	Book   *models.Book `json:"book,omitempty"`
+	// Error says why the book was not created; Errors lists the invalid fields, if that is why.
+	Error  string             `json:"error,omitempty" example:"Invalid book: title is required"`
+	Errors models.FieldErrors `json:"errors,omitempty"`
+}
+
+// BulkResult is the response of a non-atomic bulk create.
+type BulkResult struct {
+	Created int              `json:"created" example:"9"`
+	Failed  int              `json:"failed" example:"1"`
+	Results []BulkItemResult `json:"results"`
+}
+
+// CreateBooks handles the creation of several books in one request.
+// @Summary Create books in bulk
+// @Description Add several books in a single transaction: either every book is created or none is.
+// @Description With atomic=false each valid book is created in a transaction of its own instead, and the
+// @Description response gives the outcome of every book, so the failure of some keeps none of the others out.
+// @Description The batch is limited to MAX_BULK_ITEMS books and MAX_BULK_BODY_BYTES bytes; above either,
+// @Description the request fails with 413 and the limits in max_items and max_bytes.
+// @Tags books
+// @Accept json
+// @Produce json
+// @Param books body []models.BookInput true "Books to create"
+// @Param atomic query bool false "Whether the books are created all or none (default), or each on its own" example(false)
+// @Success 201 {array} models.Book
+// @Success 200 {object} BulkResult "Outcome of each book, with atomic=false"
+// @Failure 400 {string} string "Invalid request body"
+// @Failure 415 {string} string "Request body not sent as application/json"
+// @Failure 413 {object} BulkLimitError
+// @Failure 409 {string} string "Another book already has this ISBN"
+// @Router /books/bulk [post]
+func CreateBooks(w http.ResponseWriter, r *http.Request, db *sql.DB, bulk config.Bulk) {
+	w.Header().Set("Content-Type", "application/json")
+	atomic := true
+	if v := r.URL.Query().Get("atomic"); v != "" {
+		var err error
+		if atomic, err = strconv.ParseBool(v); err != nil {
+			respond.Error(w, "Invalid atomic: must be true or false", http.StatusBadRequest)
+			return
+		}
+	}
+	books, ok := decodeBulkBooks(w, r, bulk)
+	if !ok {
+		return
+	}
+	if !atomic {
+		createBooksIndependently(w, r, db, books)
+		return
+	}
+
+	now := time.Now()
+	for i := range books {
+		keepHiddenFields(r, &books[i], models.Book{})
+		if errs := validateNewBook("CreateBooks", &books[i], now); errs != nil {
+			respond.Error(w, fmt.Sprintf("Invalid request body: book %d: %s", i, errs.Error()), http.StatusBadRequest)
+			return
+		}
+	}
+
+	err := insertBooks(r, db, books)
+	if message, conflict := conflictMessage(err); conflict {
+		respond.Error(w, message+", or the batch repeats it", http.StatusConflict)
+		return
+	}
+	if err != nil {
+		respond.Error(w, fmt.Sprintf("Database insert failed: %v", err), http.StatusInternalServerError)
+		return
+	}
+
+	respond.JSON(w, r, http.StatusCreated, books)
+}
+
+// createBooksIndependently creates each valid book of a bulk request in a transaction of its own,
+// for best-effort imports: a book that is invalid or fails to insert is reported and skipped, and
+// the books created before or after it are kept.
+func createBooksIndependently(w http.ResponseWriter, r *http.Request, db *sql.DB, books []models.Book) {
+	result := BulkResult{Results: make([]BulkItemResult, len(books))}
+	now := time.Now()
+	for i := range books {
+		keepHiddenFields(r, &books[i], models.Book{})
+		item := BulkItemResult{Index: i, Status: bulkFailed}
+		if errs := validateNewBook("CreateBooks", &books[i], now); errs != nil {
+			item.Error, item.Errors = "Invalid book: "+errs.Error(), errs
+		} else if err := insertBooks(r, db, books[i:i+1]); err != nil {
+			if message, conflict := conflictMessage(err); conflict {
+				item.Error = message
+			} else {
+				item.Error = fmt.Sprintf("Database insert failed: %v", err)
+			}
+		} else {
+			item.Status, item.Book = bulkCreated, &books[i]
+		}
+		if item.Status == bulkCreated {
+			result.Created++
+		} else {
+			result.Failed++
+		}
+		result.Results[i] = item
+	}
+	respond.JSON(w, r, http.StatusOK, result)
+}
+
+// decodeBulkBooks decodes the books of a bulk request body within the limits of bulk. It has
+// answered the request when it returns false.
+func decodeBulkBooks(w http.ResponseWriter, r *http.Request, bulk config.Bulk) ([]models.Book, bool) {
+	limitError := func(message string) {
+		respond.Fail(w, r, http.StatusRequestEntityTooLarge, BulkLimitError{Error: message, MaxItems: bulk.MaxItems, MaxBytes: bulk.MaxBodyBytes})
+	}
+
+	var books []models.Book
+	err := json.NewDecoder(http.MaxBytesReader(w, r.Body, bulk.MaxBodyBytes)).Decode(&books)
+	var tooLarge *http.MaxBytesError
+	switch {
+	case errors.As(err, &tooLarge):
+		limitError(fmt.Sprintf("Request body too large: at most %d bytes per request, split the batch", bulk.MaxBodyBytes))
+		return nil, false
+	case errors.Is(err, io.EOF):
+		respond.Error(w, "Request body is required", http.StatusBadRequest)
+		return nil, false
+	case err != nil:
+		respond.Error(w, fmt.Sprintf("Invalid request body: %v", err), http.StatusBadRequest)
+		return nil, false
+	}
+	if len(books) == 0 {
+		respond.Error(w, "Invalid request body: at least one book is required", http.StatusBadRequest)
+		return nil, false
+	}
+	if len(books) > bulk.MaxItems {
+		limitError(fmt.Sprintf("Too many books (%d): at most %d per request, split the batch", len(books), bulk.MaxItems))
+		return nil, false
+	}
+	return books, true
+}
+
+// insertBooks creates validated books, setting their ids, and audits them, all in one
+// transaction: either every book is created or none is.
+func insertBooks(r *http.Request, db *sql.DB, books []models.Book) error {
+	// Insert the books one by one, so each gets its own id.
+	return database.WithTx(r.Context(), db, func(tx *sql.Tx) error {
+		stmt, err := tx.PrepareContext(r.Context(), fmt.Sprintf("INSERT INTO %s (`title`, `author`, `publication_year`, `cover_url`, `notes`, `isbn`) VALUES (?, ?, ?, ?, ?, NULLIF(?, ''))", database.Table(database.Books)))
+		if err != nil {
+			return err
+		}
+		defer stmt.Close()
+		for i := range books {
+			book := &books[i]
+			result, err := stmt.ExecContext(r.Context(), book.Title, book.Author, book.Year, book.CoverURL, book.Notes, book.ISBN)
+			if err != nil {
+				return err
+			}
+			if book.ID, err = result.LastInsertId(); err != nil {
+				return fmt.Errorf("failed to get last insert ID: %w", err)
+			}
+			if err := database.RecordAudit(tx, database.EntityBook, book.ID, database.ActionCreate, auth.Actor(r.Context()), *book); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
@@ -0,0 +1,102 @@
+package controllers
+
+import (
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"github.com/gorilla/mux"
+	"golang-api-rest-swagger/Core/Books/database"
+	"golang-api-rest-swagger/Core/Books/models"
+	"golang-api-rest-swagger/Core/Shared/auth"
+	"golang-api-rest-swagger/Core/Shared/respond"
+	"io"
+	"net/http"
+	"strconv"
+)
+
+// cloneTitlePrefix is prepended to the title of a clone when the request does not give one.
+const cloneTitlePrefix = "Copy of "
+
+// CloneOptions is the optional request body of CloneBook.
+type CloneOptions struct {
+	// Title of the new book; defaults to "Copy of " followed by the source title.
+	Title string `json:"title" example:"The Hobbit (2nd edition)"`
+}
+
+// CloneBook handles copying an existing book into a new one.
+// @Summary Clone a book
+// @Description Copy an existing book into a new book with a new ID. The title of the copy is taken from the
+// @Description optional body, or defaults to "Copy of" followed by the source title. The ISBN, which identifies
+// @Description a single edition, is not copied.
+// @Tags books
+// @Accept json
+// @Produce json
+// @Param id path int true "ID of the book to copy" example(1)
+// @Param options body CloneOptions false "Title of the copy"
+// @Success 201 {object} models.Book
+// @Header 201 {string} Location "URL of the new book"
+// @Failure 400 {string} string "Invalid request body"
+// @Failure 415 {string} string "Request body not sent as application/json"
+// @Failure 404 {string} string "Book not found"
+// @Failure 409 {string} string "Another book already has this title and author, when ENFORCE_UNIQUE_TITLE_AUTHOR is enabled"
+// @Router /books/{id}/clone [post]
+func CloneBook(w http.ResponseWriter, r *http.Request, db *sql.DB) {
+	w.Header().Set("Content-Type", "application/json")
+	id, err := strconv.ParseInt(mux.Vars(r)["id"], 10, 64)
+	if err != nil {
+		respond.Error(w, "Invalid book ID", http.StatusBadRequest)
+		return
+	}
+
+	// The body is optional, so an empty one is not an error.
+	var options CloneOptions
+	if err := json.NewDecoder(r.Body).Decode(&options); err != nil && !errors.Is(err, io.EOF) {
+		respond.Error(w, fmt.Sprintf("Invalid request body: %v", err), http.StatusBadRequest)
+		return
+	}
+
+	// Read the source and insert the copy in one transaction, so the copy matches a committed row.
+	table := database.Table(database.Books)
+	var clone models.Book
+	err = database.WithTx(r.Context(), db, func(tx *sql.Tx) error {
+		err := tx.QueryRowContext(r.Context(), fmt.Sprintf("SELECT `id`, `title`, `author`, `publication_year`, `cover_url`, `notes` FROM %s WHERE `id` = ? AND `deleted_at` IS NULL", table), id).
+			Scan(&clone.ID, &clone.Title, &clone.Author, &clone.Year, &clone.CoverURL, &clone.Notes)
+		if err != nil {
+			return err
+		}
+		if options.Title != "" {
+			clone.Title = options.Title
+		} else {
+			clone.Title = cloneTitlePrefix + clone.Title
+		}
+		if errs := validateBook("CloneBook", clone); errs != nil {
+			return errs
+		}
+
+		result, err := tx.ExecContext(r.Context(), fmt.Sprintf("INSERT INTO %s (`title`, `author`, `publication_year`, `cover_url`, `notes`) VALUES (?, ?, ?, ?, ?)", table), clone.Title, clone.Author, clone.Year, clone.CoverURL, clone.Notes)
+		if err != nil {
+			return err
+		}
+		if clone.ID, err = result.LastInsertId(); err != nil {
+			return fmt.Errorf("failed to get last insert ID: %w", err)
+		}
+		return database.RecordAudit(tx, database.EntityBook, clone.ID, database.ActionCreate, auth.Actor(r.Context()), clone)
+	})
+	var errs models.FieldErrors
+	switch {
+	case err == sql.ErrNoRows:
+		respond.Error(w, "Book not found", http.StatusNotFound)
+	case errors.As(err, &errs):
+		// Typically the default title is longer than allowed; the client can pick a shorter one.
+		respond.Error(w, "Invalid request body: "+errs.Error(), http.StatusBadRequest)
+	case database.DuplicateKey(err) != "":
+		message, _ := conflictMessage(err)
+		respond.Error(w, message, http.StatusConflict)
+	case err != nil:
+		respond.Error(w, fmt.Sprintf("Database insert failed: %v", err), http.StatusInternalServerError)
+	default:
+		w.Header().Set("Location", fmt.Sprintf("/books/%d", clone.ID))
+		respond.JSON(w, r, http.StatusCreated, clone)
+	}
+}
@@ -0,0 +1,135 @@
+package controllers
+
+import (
+	"database/sql"
+	"fmt"
+	"golang-api-rest-swagger/Core/Books/database"
+	"golang-api-rest-swagger/Core/Books/models"
+	"golang-api-rest-swagger/Core/Shared/respond"
+	"net/http"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+// maxCompareBooks caps the number of books compared at once, what a side-by-side view can show.
+const maxCompareBooks = 5
+
+// BookComparison is the response of CompareBooks. Every list of Fields holds one value per book,
+// in the order of IDs, so a comparison table reads them column by column.
+type BookComparison struct {
+	IDs    []int64          `json:"ids" example:"1,2"`
+	Fields ComparisonFields `json:"fields"`
+	// Differences names the fields whose values are not the same for every book.
+	Differences []string `json:"differences" example:"title,year"`
+}
+
+// ComparisonFields holds the values of each book field, aligned with BookComparison.IDs.
+type ComparisonFields struct {
+	Title    []string `json:"title" example:"The Hobbit,The Silmarillion"`
+	Author   []string `json:"author" example:"J. R. R. Tolkien,J. R. R. Tolkien"`
+	Year     []int    `json:"year" example:"1937,1977"`
+	CoverURL []string `json:"cover_url" example:","`
+	ISBN     []string `json:"isbn" example:"9780261102217,9780261102736"`
+}
+
+// CompareBooks handles the side-by-side comparison of a few books.
+// @Summary Compare books
+// @Description Return the requested books aligned field by field, in the order of ids, with the names
+// @Description of the fields that differ between them. Between 2 and 5 distinct ids are accepted.
+// @Tags books
+// @Produce json
+// @Param ids query string true "Comma separated list of 2 to 5 book IDs" example(1,2)
+// @Success 200 {object} BookComparison
+// @Failure 400 {string} string "Invalid ids"
+// @Failure 404 {string} string "Books not found"
+// @Router /books/compare [get]
+func CompareBooks(w http.ResponseWriter, r *http.Request, db *sql.DB) {
+	w.Header().Set("Content-Type", "application/json")
+
+	values, err := parseIDList(r.URL.Query()["ids"])
+	if err != nil {
+		respond.Error(w, fmt.Sprintf("Invalid ids: %v", err), http.StatusBadRequest)
+		return
+	}
+	if len(values) < 2 || len(values) > maxCompareBooks {
+		respond.Error(w, fmt.Sprintf("Invalid ids: between 2 and %d ids are compared", maxCompareBooks), http.StatusBadRequest)
+		return
+	}
+	ids := make([]int64, len(values))
+	for i, value := range values {
+		ids[i] = value.(int64)
+		if slices.Contains(ids[:i], ids[i]) {
+			respond.Error(w, fmt.Sprintf("Invalid ids: %d is listed more than once", ids[i]), http.StatusBadRequest)
+			return
+		}
+	}
+
+	rows, err := db.QueryContext(r.Context(), fmt.Sprintf("SELECT `id`, `title`, `author`, `publication_year`, `cover_url`, COALESCE(`isbn`, '') FROM %s WHERE `deleted_at` IS NULL AND `id` IN (%s)", database.Table(database.Books), placeholders(len(values))), values...)
+	if err != nil {
+		respond.Error(w, fmt.Sprintf("Database query failed: %v", err), http.StatusInternalServerError)
+		return
+	}
+	defer rows.Close()
+
+	byID := make(map[int64]models.Book, len(ids))
+	for rows.Next() {
+		var book models.Book
+		if err := rows.Scan(&book.ID, &book.Title, &book.Author, &book.Year, &book.CoverURL, &book.ISBN); err != nil {
+			respond.Error(w, fmt.Sprintf("Failed to scan row: %v", err), http.StatusInternalServerError)
+			return
+		}
+		byID[book.ID] = book
+	}
+	if err := rows.Err(); err != nil {
+		respond.Error(w, fmt.Sprintf("Error during row iteration: %v", err), http.StatusInternalServerError)
+		return
+	}
+
+	var missing []string
+	comparison := BookComparison{IDs: ids, Differences: []string{}}
+	fields := &comparison.Fields
+	for _, id := range ids {
+		book, ok := byID[id]
+		if !ok {
+			missing = append(missing, strconv.FormatInt(id, 10))
+			continue
+		}
+		fields.Title = append(fields.Title, book.Title)
+		fields.Author = append(fields.Author, book.Author)
+		fields.Year = append(fields.Year, book.Year)
+		fields.CoverURL = append(fields.CoverURL, book.CoverURL)
+		fields.ISBN = append(fields.ISBN, book.ISBN)
+	}
+	if len(missing) > 0 {
+		respond.Error(w, "Books not found: "+strings.Join(missing, ", "), http.StatusNotFound)
+		return
+	}
+
+	for _, field := range []struct {
+		name   string
+		differ bool
+	}{
+		{"title", differs(fields.Title)},
+		{"author", differs(fields.Author)},
+		{"year", differs(fields.Year)},
+		{"cover_url", differs(fields.CoverURL)},
+		{"isbn", differs(fields.ISBN)},
+	} {
+		if field.differ {
+			comparison.Differences = append(comparison.Differences, field.name)
+		}
+	}
+
+	respond.JSON(w, r, http.StatusOK, comparison)
+}
+
+// differs reports whether the values are not all equal.
+func differs[T comparable](values []T) bool {
+	for _, v := range values[1:] {
+		if v != values[0] {
+			return true
+		}
+	}
+	return false
+}
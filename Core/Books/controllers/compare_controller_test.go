@@ -0,0 +1,31 @@
+package controllers
+
+import (
+	"database/sql"
+	"net/http"
+	"strings"
+	"testing"
+)
+
+func TestCompareBooksRejectsInvalidIDs(t *testing.T) {
+	// A nil database: the ids are checked before any query.
+	var db *sql.DB
+	tests := []struct {
+		query string
+		want  string
+	}{
+		{"", "ids must contain at least one id"},
+		{"ids=", "ids must contain at least one id"},
+		{"ids=1", "between 2 and"},
+		{"ids=1,abc", "comma separated list of integers"},
+		{"ids=1,9223372036854775808", "comma separated list of integers"},
+		{"ids=1,2,1", "1 is listed more than once"},
+		{"ids=1,2,3,4,5,6", "between 2 and"},
+	}
+	for _, tt := range tests {
+		rec := serve(func(w http.ResponseWriter, r *http.Request) { CompareBooks(w, r, db) }, "GET", "/books/compare?"+tt.query, nil, "")
+		if rec.Code != http.StatusBadRequest || !strings.Contains(rec.Body.String(), tt.want) {
+			t.Errorf("GET /books/compare?%s = %d %q, want 400 %q", tt.query, rec.Code, rec.Body, tt.want)
+		}
+	}
+}
@@ -0,0 +1,78 @@
+package controllers
+
+import (
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"golang-api-rest-swagger/Core/Books/models"
+	"io"
+	"strconv"
+	"strings"
+)
+
+// errTooManyBooks is returned by parseBooksCSV when the file holds more books than allowed.
+var errTooManyBooks = errors.New("too many books")
+
+// parseBooksCSV reads books from CSV data whose header row names the columns: title, author and
+// year are required, cover_url and isbn are optional and id is ignored, so a file from GET /books/export
+// can be imported as is. It fails with errTooManyBooks, wrapped, beyond maxItems books.
+func parseBooksCSV(data io.Reader, maxItems int) ([]models.Book, error) {
+	in := csv.NewReader(data)
+	in.TrimLeadingSpace = true
+	header, err := in.Read()
+	if errors.Is(err, io.EOF) {
+		return nil, fmt.Errorf("the file is empty")
+	}
+	if err != nil {
+		return nil, err
+	}
+	columns := map[string]int{}
+	for i, name := range header {
+		// Spreadsheets often save UTF-8 files with a byte order mark before the first column.
+		name = strings.ToLower(strings.TrimSpace(strings.TrimPrefix(name, "\ufeff")))
+		switch name {
+		case "id", "title", "author", "year", "cover_url", "isbn":
+		default:
+			return nil, fmt.Errorf("unknown column %q, expected title, author, year and optionally cover_url and isbn", name)
+		}
+		if _, ok := columns[name]; ok {
+			return nil, fmt.Errorf("column %s appears more than once", name)
+		}
+		columns[name] = i
+	}
+	for _, name := range []string{"title", "author", "year"} {
+		if _, ok := columns[name]; !ok {
+			return nil, fmt.Errorf("missing column %s", name)
+		}
+	}
+
+	books := []models.Book{}
+	for {
+		record, err := in.Read()
+		if errors.Is(err, io.EOF) {
+			return books, nil
+		}
+		if err != nil {
+			return nil, err
+		}
+		line, _ := in.FieldPos(0)
+		if len(books) == maxItems {
+			return nil, fmt.Errorf("%w: at most %d per import", errTooManyBooks, maxItems)
+		}
+		book := models.Book{Title: record[columns["title"]], Author: record[columns["author"]]}
+		// An empty year defaults to the current one, like a missing year when creating a book.
+		if year := strings.TrimSpace(record[columns["year"]]); year != "" {
+			if book.Year, err = strconv.Atoi(year); err != nil {
+				return nil, fmt.Errorf("line %d: year must be an integer, got %q", line, year)
+			}
+		}
+		if i, ok := columns["cover_url"]; ok {
+			book.CoverURL = record[i]
+		}
+		// An empty ISBN is stored as NULL, as for the other writes.
+		if i, ok := columns["isbn"]; ok {
+			book.ISBN = strings.TrimSpace(record[i])
+		}
+		books = append(books, book)
+	}
+}
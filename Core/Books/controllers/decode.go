@@ -0,0 +1,34 @@
+package controllers
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"golang-api-rest-swagger/Core/Books/models"
+	"golang-api-rest-swagger/Core/Shared/auth"
+	"golang-api-rest-swagger/Core/Shared/visibility"
+	"io"
+	"net/http"
+)
+
+// decodeJSON decodes the JSON request body into v. The returned error is meant to be sent
+// to the client as is; a request without any body is reported as such instead of as an EOF.
+func decodeJSON(r *http.Request, v any) error {
+	err := json.NewDecoder(r.Body).Decode(v)
+	if errors.Is(err, io.EOF) {
+		return errors.New("Request body is required")
+	}
+	if err != nil {
+		return fmt.Errorf("Invalid request body: %v", err)
+	}
+	return nil
+}
+
+// keepHiddenFields resets the fields of a decoded book that the caller may not see to their
+// stored values, zero for a new book, so callers cannot write what they cannot read. The
+// timestamps, kept by the database, are reset the same way.
+func keepHiddenFields(r *http.Request, book *models.Book, stored models.Book) {
+	principal, _ := auth.FromContext(r.Context())
+	visibility.Restore(book, &stored, principal.Role)
+	book.CreatedAt, book.UpdatedAt = stored.CreatedAt, stored.UpdatedAt
+}
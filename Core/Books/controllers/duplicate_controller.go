@@ -0,0 +1,88 @@
+package controllers
+
+import (
+	"database/sql"
+	"fmt"
+	"golang-api-rest-swagger/Core/Books/database"
+	"golang-api-rest-swagger/Core/Books/models"
+	"golang-api-rest-swagger/Core/Shared/config"
+	"golang-api-rest-swagger/Core/Shared/respond"
+	"net/http"
+	"strconv"
+	"strings"
+)
+
+// GetDuplicateBooks handles finding likely duplicate books for manual clean up.
+// @Summary Find duplicate books
+// @Description List groups of books with the same title and author, ignoring case and surrounding spaces.
+// @Description Groups are ordered by size and paginated. Requires the admin API key.
+// @Tags books
+// @Produce json
+// @Security ApiKeyAuth
+// @Param page query int false "Page number, starting at 1" example(1)
+// @Param limit query int false "Number of groups per page, at most MAX_PAGE_SIZE" example(20)
+// @Success 200 {array} models.DuplicateGroup
+// @Header 200 {integer} X-Total-Count "Total number of duplicate groups"
+// @Header 200 {string} Link "URLs of the self, first, last, prev and next pages"
+// @Failure 400 {string} string "Invalid pagination parameters"
+// @Failure 401 {string} string "Unauthorized"
+// @Router /books/duplicates [get]
+func GetDuplicateBooks(w http.ResponseWriter, r *http.Request, db *sql.DB, listing config.Listing) {
+	w.Header().Set("Content-Type", "application/json")
+
+	pagination, err := parsePagination(r, listing)
+	if err != nil {
+		respond.Error(w, fmt.Sprintf("Invalid pagination parameters: %v", err), http.StatusBadRequest)
+		return
+	}
+	if pagination == nil {
+		pagination = &Pagination{Page: 1, Limit: min(defaultPageSize, listing.MaxPageSize)}
+	}
+
+	groups := fmt.Sprintf(
+		"SELECT LOWER(TRIM(`title`)) AS `normalized_title`, LOWER(TRIM(`author`)) AS `normalized_author`, "+
+			"COUNT(*) AS `copies`, GROUP_CONCAT(`id` ORDER BY `id`) AS `ids` "+
+			"FROM %s "+
+			"WHERE `deleted_at` IS NULL "+
+			"GROUP BY `normalized_title`, `normalized_author` "+
+			"HAVING COUNT(*) > 1", database.Table(database.Books))
+
+	// Count the groups so clients can compute the number of pages.
+	var total int
+	if err := db.QueryRowContext(r.Context(), "SELECT COUNT(*) FROM ("+groups+") AS `duplicate_groups`").Scan(&total); err != nil {
+		respond.Error(w, fmt.Sprintf("Database query failed: %v", err), http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("X-Total-Count", strconv.Itoa(total))
+	setPaginationLinks(w, r, pagination, total)
+
+	rows, err := db.QueryContext(r.Context(), groups+" ORDER BY `copies` DESC, `normalized_title`, `normalized_author` LIMIT ? OFFSET ?", pagination.Limit, pagination.Offset())
+	if err != nil {
+		respond.Error(w, fmt.Sprintf("Database query failed: %v", err), http.StatusInternalServerError)
+		return
+	}
+	defer rows.Close()
+
+	result := []models.DuplicateGroup{}
+	for rows.Next() {
+		var group models.DuplicateGroup
+		var ids string
+		if err := rows.Scan(&group.Title, &group.Author, &group.Count, &ids); err != nil {
+			respond.Error(w, fmt.Sprintf("Failed to scan row: %v", err), http.StatusInternalServerError)
+			return
+		}
+		for _, id := range strings.Split(ids, ",") {
+			if n, err := strconv.ParseInt(id, 10, 64); err == nil {
+				group.IDs = append(group.IDs, n)
+			}
+		}
+		result = append(result, group)
+	}
+
+	if err := rows.Err(); err != nil {
+		respond.Error(w, fmt.Sprintf("Error during row iteration: %v", err), http.StatusInternalServerError)
+		return
+	}
+
+	respond.JSON(w, r, http.StatusOK, result)
+}
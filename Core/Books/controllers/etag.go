@@ -0,0 +1,33 @@
+package controllers
+
+import (
+	"fmt"
+	"hash/crc32"
+	"strings"
+)
+
+// listETag returns the weak ETag of a list of total books whose last change, as a Unix timestamp,
+// is lastUpdate, sorted by order. Both are taken over every book matching the request, not only the page, so a
+// change shifting the page boundaries is seen too: a book can only join the matches by being
+// changed, which moves lastUpdate past every other, and one leaving them lowers total unless
+// another joins. The filters and page are in the URL, which the ETag is scoped to; the order is
+// not when it is the default one, which DEFAULT_SORT can change, so it is hashed in. It is weak
+// because the bytes also depend on settings, like pretty printing, that do not change the list.
+func listETag(total int, lastUpdate, order string) string {
+	return fmt.Sprintf(`W/"%d-%s-%08x"`, total, strings.ReplaceAll(lastUpdate, ".", ""), crc32.ChecksumIEEE([]byte(order)))
+}
+
+// etagMatches reports whether the If-None-Match header lists etag, or is *. The comparison is
+// weak, as required for If-None-Match: the W/ prefixes are ignored.
+func etagMatches(ifNoneMatch, etag string) bool {
+	if strings.TrimSpace(ifNoneMatch) == "*" {
+		return true
+	}
+	etag = strings.TrimPrefix(etag, "W/")
+	for _, candidate := range strings.Split(ifNoneMatch, ",") {
+		if strings.TrimPrefix(strings.TrimSpace(candidate), "W/") == etag {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,120 @@
+package controllers
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"errors"
+	"io"
+	"sync"
+	"testing"
+)
+
+// fakeResult is what the fake database answers to a query: the rows of columns, or err.
+type fakeResult struct {
+	columns []string
+	rows    [][]driver.Value
+	err     error
+}
+
+// fakeDriver is a database/sql driver answering every query of a connection with the results
+// of its DSN, in turn, so handlers can be tested without a MySQL server.
+type fakeDriver struct {
+	mu      sync.Mutex
+	results map[string][]fakeResult
+	queries map[string][]string
+}
+
+var fakeDB = &fakeDriver{results: map[string][]fakeResult{}, queries: map[string][]string{}}
+
+func init() {
+	sql.Register("fake", fakeDB)
+}
+
+// openFakeDB returns a database answering its queries with results, in turn, and the function
+// returning the queries it ran.
+func openFakeDB(t *testing.T, results ...fakeResult) (*sql.DB, func() []string) {
+	t.Helper()
+	name := t.Name()
+	fakeDB.mu.Lock()
+	fakeDB.results[name] = results
+	fakeDB.queries[name] = nil
+	fakeDB.mu.Unlock()
+	db, err := sql.Open("fake", name)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() {
+		db.Close()
+		fakeDB.mu.Lock()
+		delete(fakeDB.results, name)
+		delete(fakeDB.queries, name)
+		fakeDB.mu.Unlock()
+	})
+	return db, func() []string {
+		fakeDB.mu.Lock()
+		defer fakeDB.mu.Unlock()
+		return append([]string(nil), fakeDB.queries[name]...)
+	}
+}
+
+func (d *fakeDriver) Open(name string) (driver.Conn, error) {
+	return &fakeConn{driver: d, name: name}, nil
+}
+
+// next records query and returns the next result of the connection name.
+func (d *fakeDriver) next(name, query string) (fakeResult, error) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	d.queries[name] = append(d.queries[name], query)
+	if len(d.results[name]) == 0 {
+		return fakeResult{}, errors.New("fake database: unexpected query " + query)
+	}
+	result := d.results[name][0]
+	d.results[name] = d.results[name][1:]
+	return result, nil
+}
+
+type fakeConn struct {
+	driver *fakeDriver
+	name   string
+}
+
+func (c *fakeConn) Prepare(query string) (driver.Stmt, error) {
+	return nil, errors.New("fake database: prepared statements are not supported")
+}
+
+func (c *fakeConn) Close() error { return nil }
+
+func (c *fakeConn) Begin() (driver.Tx, error) {
+	return nil, errors.New("fake database: transactions are not supported")
+}
+
+func (c *fakeConn) QueryContext(ctx context.Context, query string, args []driver.NamedValue) (driver.Rows, error) {
+	result, err := c.driver.next(c.name, query)
+	if err != nil {
+		return nil, err
+	}
+	if result.err != nil {
+		return nil, result.err
+	}
+	return &fakeRows{columns: result.columns, rows: result.rows}, nil
+}
+
+type fakeRows struct {
+	columns []string
+	rows    [][]driver.Value
+}
+
+func (r *fakeRows) Columns() []string { return r.columns }
+
+func (r *fakeRows) Close() error { return nil }
+
+func (r *fakeRows) Next(dest []driver.Value) error {
+	if len(r.rows) == 0 {
+		return io.EOF
+	}
+	copy(dest, r.rows[0])
+	r.rows = r.rows[1:]
+	return nil
+}
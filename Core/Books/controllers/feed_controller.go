@@ -0,0 +1,110 @@
+package controllers
+
+import (
+	"bytes"
+	"database/sql"
+	"encoding/xml"
+	"fmt"
+	"golang-api-rest-swagger/Core/Books/database"
+	"golang-api-rest-swagger/Core/Shared/respond"
+	"log"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+// feedItems is the number of books in the feed, the most recently added ones.
+const feedItems = 20
+
+// rssFeed is an RSS 2.0 document.
+type rssFeed struct {
+	XMLName xml.Name   `xml:"rss"`
+	Version string     `xml:"version,attr"`
+	Channel rssChannel `xml:"channel"`
+}
+
+type rssChannel struct {
+	Title         string    `xml:"title"`
+	Link          string    `xml:"link"`
+	Description   string    `xml:"description"`
+	LastBuildDate string    `xml:"lastBuildDate,omitempty"`
+	Items         []rssItem `xml:"item"`
+}
+
+type rssItem struct {
+	Title       string  `xml:"title"`
+	Link        string  `xml:"link"`
+	Description string  `xml:"description"`
+	GUID        rssGUID `xml:"guid"`
+	PubDate     string  `xml:"pubDate"`
+}
+
+type rssGUID struct {
+	IsPermaLink bool   `xml:"isPermaLink,attr"`
+	ID          string `xml:",chardata"`
+}
+
+// GetBookFeed handles the RSS feed of the latest books.
+// @Summary Latest books feed
+// @Description The most recently added books as an RSS 2.0 feed, newest first, for feed readers
+// @Tags books
+// @Produce application/rss+xml
+// @Success 200 {string} string "RSS 2.0 document"
+// @Router /books/feed.xml [get]
+func GetBookFeed(w http.ResponseWriter, r *http.Request, db *sql.DB) {
+	rows, err := db.QueryContext(r.Context(), fmt.Sprintf("SELECT `id`, `title`, `author`, `publication_year`, `created_at` FROM %s WHERE `deleted_at` IS NULL AND `archived_at` IS NULL ORDER BY `created_at` DESC, `id` DESC LIMIT ?", database.Table(database.Books)), feedItems)
+	if err != nil {
+		respond.Error(w, fmt.Sprintf("Database query failed: %v", err), http.StatusInternalServerError)
+		return
+	}
+	defer rows.Close()
+
+	feed := rssFeed{Version: "2.0", Channel: rssChannel{
+		Title:       "Latest books",
+		Link:        absoluteURL(r, "/books", ""),
+		Description: fmt.Sprintf("The %d most recently added books", feedItems),
+		Items:       []rssItem{},
+	}}
+	for rows.Next() {
+		var (
+			id        int64
+			title     string
+			author    string
+			year      int
+			createdAt time.Time
+		)
+		if err := rows.Scan(&id, &title, &author, &year, &createdAt); err != nil {
+			respond.Error(w, fmt.Sprintf("Failed to scan row: %v", err), http.StatusInternalServerError)
+			return
+		}
+		link := absoluteURL(r, "/books/"+strconv.FormatInt(id, 10), "")
+		feed.Channel.Items = append(feed.Channel.Items, rssItem{
+			Title:       title,
+			Link:        link,
+			Description: fmt.Sprintf("%s, %d", author, year),
+			GUID:        rssGUID{IsPermaLink: true, ID: link},
+			PubDate:     createdAt.UTC().Format(time.RFC1123Z),
+		})
+	}
+	if err := rows.Err(); err != nil {
+		respond.Error(w, fmt.Sprintf("Error during row iteration: %v", err), http.StatusInternalServerError)
+		return
+	}
+	if len(feed.Channel.Items) > 0 {
+		feed.Channel.LastBuildDate = feed.Channel.Items[0].PubDate
+	}
+
+	var body bytes.Buffer
+	body.WriteString(xml.Header)
+	enc := xml.NewEncoder(&body)
+	enc.Indent("", "  ")
+	if err := enc.Encode(feed); err != nil {
+		log.Printf("Failed to encode feed: %v", err)
+		respond.Error(w, "Failed to encode response", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/rss+xml; charset=utf-8")
+	w.Header().Set("Content-Length", strconv.Itoa(body.Len()))
+	w.Write(body.Bytes())
+}
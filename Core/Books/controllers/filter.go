@@ -0,0 +1,273 @@
+package controllers
+
+import (
+	"fmt"
+	"golang-api-rest-swagger/Core/Books/database"
+	"net/http"
+	"slices"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+)
+
+// maxFilterIDs caps the number of ids accepted by the ids filter.
+const maxFilterIDs = 100
+
+// maxFilterAuthors caps the number of authors accepted by the author filter.
+const maxFilterAuthors = 20
+
+// maxFilterYears caps the number of years accepted by the year_not exclusion.
+const maxFilterYears = 20
+
+// maxStartsWithLength caps the length of the starts_with title prefix.
+const maxStartsWithLength = 20
+
+// maxActorLength is the size of the actor column of the audit log, the longest modified_by.
+const maxActorLength = 255
+
+// likeEscaper escapes the LIKE wildcards, so a prefix only matches literally.
+var likeEscaper = strings.NewReplacer(`\`, `\\`, "%", `\%`, "_", `\_`)
+
+// bookFilter collects the WHERE conditions, and their arguments, of a book list query.
+type bookFilter struct {
+	// scope are the conditions every result must meet whatever the match mode, like excluding
+	// soft deleted books. They take no arguments.
+	scope      []string
+	conditions []string
+	args       []any
+	// exclusions are the negated filters, like author_not. Like the scope they apply whatever the
+	// match mode, after the conditions, with their own arguments.
+	exclusions    []string
+	exclusionArgs []any
+	// matchAny makes a book match when any of the conditions holds instead of all of them.
+	matchAny bool
+}
+
+// add appends a condition, using ? placeholders for args, to the filter.
+func (f *bookFilter) add(condition string, args ...any) {
+	f.conditions = append(f.conditions, condition)
+	f.args = append(f.args, args...)
+}
+
+// exclude appends a condition every result must meet, using ? placeholders for args, to the filter.
+func (f *bookFilter) exclude(condition string, args ...any) {
+	f.exclusions = append(f.exclusions, condition)
+	f.exclusionArgs = append(f.exclusionArgs, args...)
+}
+
+// arguments returns the arguments of the WHERE clause, in the order of its placeholders.
+func (f *bookFilter) arguments() []any {
+	return append(append([]any{}, f.args...), f.exclusionArgs...)
+}
+
+// scopeOnly reports whether the filter has no conditions besides its scope, which the
+// idx_books_scope index covers.
+func (f *bookFilter) scopeOnly() bool {
+	return len(f.conditions) == 0 && len(f.exclusions) == 0
+}
+
+// where returns the WHERE clause of the filter, or an empty string when it has no conditions.
+func (f *bookFilter) where() string {
+	conditions := f.scope
+	if len(f.conditions) > 0 {
+		joiner := " AND "
+		if f.matchAny {
+			joiner = " OR "
+		}
+		conditions = append(slices.Clip(conditions), "("+strings.Join(f.conditions, joiner)+")")
+	}
+	conditions = append(slices.Clip(conditions), f.exclusions...)
+	if len(conditions) == 0 {
+		return ""
+	}
+	return " WHERE " + strings.Join(conditions, " AND ")
+}
+
+// whereAnd returns the WHERE clause of the filter with condition added, which every result must
+// meet whatever the match mode. The caller appends the arguments of condition to the filter's arguments.
+func (f *bookFilter) whereAnd(condition string) string {
+	if where := f.where(); where != "" {
+		return where + " AND " + condition
+	}
+	return " WHERE " + condition
+}
+
+// parseBookFilter reads the filter query parameters of GetBooks.
+func parseBookFilter(r *http.Request) (*bookFilter, error) {
+	filter := &bookFilter{}
+	query := r.URL.Query()
+
+	// Soft deleted books are never listed, archived ones only when asked for.
+	filter.scope = append(filter.scope, "`deleted_at` IS NULL")
+	switch status := query.Get("status"); status {
+	case "", statusActive:
+		filter.scope = append(filter.scope, "`archived_at` IS NULL")
+	case statusArchived:
+		filter.scope = append(filter.scope, "`archived_at` IS NOT NULL")
+	default:
+		return nil, fmt.Errorf("status must be active or archived")
+	}
+
+	// match=any returns the books matching any of the filters below instead of all of them.
+	switch match := query.Get("match"); match {
+	case "", "all":
+	case "any":
+		filter.matchAny = true
+	default:
+		return nil, fmt.Errorf("match must be all or any")
+	}
+
+	// ids accepts both ?ids=1,2,3 and ?ids=1&ids=2.
+	if query.Has("ids") {
+		ids, err := parseIDList(query["ids"])
+		if err != nil {
+			return nil, err
+		}
+		filter.add("`id` IN ("+placeholders(len(ids))+")", ids...)
+	}
+
+	// author accepts both ?author=Tolkien,Lewis and ?author=Tolkien&author=Lewis.
+	if query.Has("author") {
+		authors, err := parseAuthorList("author", query["author"])
+		if err != nil {
+			return nil, err
+		}
+		filter.add("`author` IN ("+placeholders(len(authors))+")", authors...)
+	}
+
+	// author_not and year_not leave out the books of the given authors and years, in the same
+	// syntax as author. They are exclusions rather than filters: with match=any, a book must still
+	// match one of the filters and none of the exclusions.
+	if query.Has("author_not") {
+		authors, err := parseAuthorList("author_not", query["author_not"])
+		if err != nil {
+			return nil, err
+		}
+		filter.exclude("`author` NOT IN ("+placeholders(len(authors))+")", authors...)
+	}
+	if query.Has("year_not") {
+		years, err := parseYearList("year_not", query["year_not"])
+		if err != nil {
+			return nil, err
+		}
+		filter.exclude("`publication_year` NOT IN ("+placeholders(len(years))+")", years...)
+	}
+
+	// starts_with matches a title prefix, ignoring case as the utf8mb4_unicode_ci collation of the
+	// column does, so the prefix can be looked up in idx_books_title. # matches titles not
+	// starting with a letter from A to Z, like the # entry of the A-Z index.
+	if query.Has("starts_with") {
+		prefix := query.Get("starts_with")
+		switch n := utf8.RuneCountInString(prefix); {
+		case n == 0:
+			return nil, fmt.Errorf("starts_with must not be empty")
+		case n > maxStartsWithLength:
+			return nil, fmt.Errorf("starts_with accepts at most %d characters", maxStartsWithLength)
+		case prefix == "#":
+			filter.add("NOT (UPPER(LEFT(`title`, 1)) BETWEEN 'A' AND 'Z')")
+		default:
+			filter.add("`title` LIKE ?", likeEscaper.Replace(prefix)+"%")
+		}
+	}
+
+	// modified_by matches the books whose latest audited change, their creation included, was made
+	// by the given actor, the subject of an API key or anonymous.
+	if query.Has("modified_by") {
+		actor := query.Get("modified_by")
+		switch n := utf8.RuneCountInString(actor); {
+		case n == 0:
+			return nil, fmt.Errorf("modified_by must not be empty")
+		case n > maxActorLength:
+			return nil, fmt.Errorf("modified_by accepts at most %d characters", maxActorLength)
+		}
+		audit := database.Table(database.AuditLog)
+		filter.add(fmt.Sprintf("`id` IN (SELECT a.`entity_id` FROM %s a WHERE a.`entity` = ? AND a.`actor` = ? "+
+			"AND a.`id` = (SELECT MAX(l.`id`) FROM %s l WHERE l.`entity` = a.`entity` AND l.`entity_id` = a.`entity_id`))", audit, audit),
+			database.EntityBook, actor)
+	}
+
+	// filter combines conditions in one expression, e.g. ?filter=author:Tolkien year:>1950.
+	if query.Has("filter") {
+		if err := addFilterExpression(filter, query.Get("filter")); err != nil {
+			return nil, err
+		}
+	}
+
+	return filter, nil
+}
+
+// placeholders returns n comma separated ? placeholders, for an IN clause.
+func placeholders(n int) string {
+	return strings.TrimSuffix(strings.Repeat("?,", n), ",")
+}
+
+// parseAuthorList parses the values of the query parameter param, each holding one or more comma
+// separated authors.
+func parseAuthorList(param string, values []string) ([]any, error) {
+	authors := []any{}
+	for _, value := range values {
+		for _, part := range strings.Split(value, ",") {
+			if part = strings.TrimSpace(part); part != "" {
+				authors = append(authors, part)
+			}
+		}
+	}
+	if len(authors) == 0 {
+		return nil, fmt.Errorf("%s must contain at least one author", param)
+	}
+	if len(authors) > maxFilterAuthors {
+		return nil, fmt.Errorf("%s accepts at most %d authors", param, maxFilterAuthors)
+	}
+	return authors, nil
+}
+
+// parseYearList parses the values of the query parameter param, each holding one or more comma
+// separated years.
+func parseYearList(param string, values []string) ([]any, error) {
+	years := []any{}
+	for _, value := range values {
+		for _, part := range strings.Split(value, ",") {
+			part = strings.TrimSpace(part)
+			if part == "" {
+				continue
+			}
+			year, err := strconv.Atoi(part)
+			if err != nil {
+				return nil, fmt.Errorf("%s must be a comma separated list of integers", param)
+			}
+			years = append(years, year)
+		}
+	}
+	if len(years) == 0 {
+		return nil, fmt.Errorf("%s must contain at least one year", param)
+	}
+	if len(years) > maxFilterYears {
+		return nil, fmt.Errorf("%s accepts at most %d years", param, maxFilterYears)
+	}
+	return years, nil
+}
+
+// parseIDList parses a list of query values, each holding one or more comma separated ids.
+func parseIDList(values []string) ([]any, error) {
+	ids := []any{}
+	for _, value := range values {
+		for _, part := range strings.Split(value, ",") {
+			part = strings.TrimSpace(part)
+			if part == "" {
+				continue
+			}
+			id, err := strconv.ParseInt(part, 10, 64)
+			if err != nil {
+				return nil, fmt.Errorf("ids must be a comma separated list of integers")
+			}
+			ids = append(ids, id)
+		}
+	}
+	if len(ids) == 0 {
+		return nil, fmt.Errorf("ids must contain at least one id")
+	}
+	if len(ids) > maxFilterIDs {
+		return nil, fmt.Errorf("ids accepts at most %d ids", maxFilterIDs)
+	}
+	return ids, nil
+}
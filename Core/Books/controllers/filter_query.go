@@ -0,0 +1,143 @@
+package controllers
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"unicode"
+)
+
+// maxFilterTerms caps the number of terms of a filter expression.
+const maxFilterTerms = 10
+
+// filterField describes a field of the filter expression syntax. column, quoted, is the only part
+// that ever reaches the SQL, so fields and operators not listed here can never be injected.
+type filterField struct {
+	column string
+	// numeric fields take integers and the comparison operators; the others take text and ~.
+	numeric bool
+}
+
+// filterFields are the fields accepted in a filter expression.
+var filterFields = map[string]filterField{
+	"title":  {column: "`title`"},
+	"author": {column: "`author`"},
+	"year":   {column: "`publication_year`", numeric: true},
+}
+
+// filterTerm is one field:value term of a filter expression. pos is the 1-based position of
+// its first character, used in error messages.
+type filterTerm struct {
+	field, op, value string
+	pos              int
+}
+
+// addFilterExpression parses a filter expression and adds its terms to filter. An expression is
+// a space separated list of field:value terms, each a filter of its own combined with the others
+// according to match (all of them by default):
+//
+//	author:Tolkien year:>1950 title:~ring
+//
+// A value is compared for equality, or preceded by an operator: ~ (contains, ignoring case) for
+// text fields, >, >=, < or <= for year. Values holding spaces are double-quoted, e.g.
+// author:"J.R.R. Tolkien".
+func addFilterExpression(filter *bookFilter, expression string) error {
+	terms, err := tokenizeFilter(expression)
+	if err != nil {
+		return err
+	}
+	if len(terms) == 0 {
+		return fmt.Errorf("filter must not be empty")
+	}
+	if len(terms) > maxFilterTerms {
+		return fmt.Errorf("filter accepts at most %d terms", maxFilterTerms)
+	}
+
+	for _, term := range terms {
+		field, ok := filterFields[term.field]
+		if !ok {
+			return fmt.Errorf("filter has an unknown field %q at position %d, expected title, author or year", term.field, term.pos)
+		}
+		if !field.numeric {
+			switch term.op {
+			case "":
+				filter.add(field.column+" = ?", term.value)
+			case "~":
+				filter.add("LOWER("+field.column+") LIKE ?", "%"+strings.ToLower(likeEscaper.Replace(term.value))+"%")
+			default:
+				return fmt.Errorf("filter operator %s is not supported by %s at position %d, use %s:value or %s:~value", term.op, term.field, term.pos, term.field, term.field)
+			}
+			continue
+		}
+
+		n, err := strconv.Atoi(term.value)
+		if err != nil {
+			return fmt.Errorf("filter value of %s at position %d must be an integer, got %q", term.field, term.pos, term.value)
+		}
+		switch term.op {
+		case "":
+			filter.add(field.column+" = ?", n)
+		case ">", ">=", "<", "<=":
+			filter.add(field.column+" "+term.op+" ?", n)
+		default:
+			return fmt.Errorf("filter operator %s is not supported by %s at position %d, use =, >, >=, < or <=", term.op, term.field, term.pos)
+		}
+	}
+	return nil
+}
+
+// tokenizeFilter splits a filter expression into its terms.
+func tokenizeFilter(expression string) ([]filterTerm, error) {
+	var terms []filterTerm
+	input := []rune(expression)
+	i := 0
+	for {
+		for i < len(input) && unicode.IsSpace(input[i]) {
+			i++
+		}
+		if i == len(input) {
+			return terms, nil
+		}
+
+		term := filterTerm{pos: i + 1}
+		start := i
+		for i < len(input) && input[i] != ':' && !unicode.IsSpace(input[i]) {
+			i++
+		}
+		term.field = strings.ToLower(string(input[start:i]))
+		if i == len(input) || input[i] != ':' || term.field == "" {
+			return nil, fmt.Errorf("filter expects field:value at position %d", term.pos)
+		}
+		i++ // the colon
+
+		for _, op := range []string{">=", "<=", ">", "<", "~"} {
+			if strings.HasPrefix(string(input[i:]), op) {
+				term.op = op
+				i += len(op)
+				break
+			}
+		}
+
+		if i < len(input) && input[i] == '"' {
+			end := i + 1
+			for end < len(input) && input[end] != '"' {
+				end++
+			}
+			if end == len(input) {
+				return nil, fmt.Errorf("filter has an unterminated quote at position %d", i+1)
+			}
+			term.value = string(input[i+1 : end])
+			i = end + 1
+		} else {
+			start = i
+			for i < len(input) && !unicode.IsSpace(input[i]) {
+				i++
+			}
+			term.value = string(input[start:i])
+		}
+		if term.value == "" {
+			return nil, fmt.Errorf("filter is missing the value of %s at position %d", term.field, term.pos)
+		}
+		terms = append(terms, term)
+	}
+}
@@ -0,0 +1,35 @@
+package controllers
+
+import (
+	"net/http/httptest"
+	"net/url"
+	"reflect"
+	"testing"
+)
+
+func TestParseBookFilterStartsWith(t *testing.T) {
+	tests := []struct {
+		prefix string
+		want   string
+		args   []any
+	}{
+		// The column collation ignores case, so the prefix is sent as is, for the title index.
+		{"The", " WHERE `deleted_at` IS NULL AND `archived_at` IS NULL AND (`title` LIKE ?)", []any{"The%"}},
+		{"the", " WHERE `deleted_at` IS NULL AND `archived_at` IS NULL AND (`title` LIKE ?)", []any{"the%"}},
+		{`10%_\`, " WHERE `deleted_at` IS NULL AND `archived_at` IS NULL AND (`title` LIKE ?)", []any{`10\%\_\\%`}},
+		{"#", " WHERE `deleted_at` IS NULL AND `archived_at` IS NULL AND (NOT (UPPER(LEFT(`title`, 1)) BETWEEN 'A' AND 'Z'))", []any{}},
+	}
+	for _, tt := range tests {
+		filter, err := parseBookFilter(httptest.NewRequest("GET", "/books?starts_with="+url.QueryEscape(tt.prefix), nil))
+		if err != nil {
+			t.Errorf("starts_with=%s failed: %v", tt.prefix, err)
+			continue
+		}
+		if got := filter.where(); got != tt.want {
+			t.Errorf("starts_with=%s: where = %s, want %s", tt.prefix, got, tt.want)
+		}
+		if got := filter.arguments(); !reflect.DeepEqual(got, tt.args) {
+			t.Errorf("starts_with=%s: arguments = %q, want %q", tt.prefix, got, tt.args)
+		}
+	}
+}
@@ -0,0 +1,214 @@
+package controllers
+
+import (
+	"bytes"
+	"database/sql"
+	"errors"
+	"fmt"
+	"golang-api-rest-swagger/Core/Books/models"
+	"golang-api-rest-swagger/Core/Shared/config"
+	"golang-api-rest-swagger/Core/Shared/respond"
+	"golang-api-rest-swagger/Core/Shared/safefetch"
+	"io"
+	"log"
+	"mime"
+	"net/http"
+	"net/url"
+	"time"
+)
+
+// ImportURLRequest is the request body of ImportBooksFromURL.
+type ImportURLRequest struct {
+	URL string `json:"url" example:"https://storage.example.com/exports/books.csv"`
+}
+
+// ImportBooksFromURL handles importing the books of a CSV file fetched from a URL.
+// @Summary Import books from a URL
+// @Description Fetch a CSV file over http or https and create its books in a single transaction: either every
+// @Description book is created or none is. The header row names the columns: title, author and year, optionally
+// @Description cover_url and isbn; id is ignored, so an export can be imported as is. The download is limited to
+// @Description IMPORT_URL_MAX_BYTES bytes and IMPORT_URL_TIMEOUT_SECONDS, the file to MAX_BULK_ITEMS books.
+// @Description URLs and redirects leading to loopback, private or link-local addresses are refused.
+// @Description Requires the admin API key.
+// @Tags books
+// @Accept json
+// @Produce json
+// @Security ApiKeyAuth
+// @Param request body ImportURLRequest true "URL of the CSV file"
+// @Success 201 {array} models.Book
+// @Failure 400 {string} string "Invalid URL or file"
+// @Failure 415 {string} string "Request body not sent as application/json"
+// @Failure 401 {string} string "Unauthorized"
+// @Failure 413 {string} string "File too large or with too many books"
+// @Failure 409 {string} string "A book with the ISBN, or the title and author when ENFORCE_UNIQUE_TITLE_AUTHOR is enabled, of another one"
+// @Failure 502 {string} string "The file could not be downloaded"
+// @Failure 504 {string} string "The download timed out"
+// @Router /books/import-url [post]
+func ImportBooksFromURL(w http.ResponseWriter, r *http.Request, db *sql.DB, importing config.Import, bulk config.Bulk) {
+	w.Header().Set("Content-Type", "application/json")
+
+	var request ImportURLRequest
+	if err := decodeJSON(r, &request); err != nil {
+		respond.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	source, err := url.Parse(request.URL)
+	if err != nil || (source.Scheme != "http" && source.Scheme != "https") || source.Host == "" {
+		respond.Error(w, "Invalid URL: must be an absolute http or https URL", http.StatusBadRequest)
+		return
+	}
+
+	req, err := http.NewRequestWithContext(r.Context(), http.MethodGet, source.String(), nil)
+	if err != nil {
+		respond.Error(w, fmt.Sprintf("Invalid URL: %v", err), http.StatusBadRequest)
+		return
+	}
+	req.Header.Set("Accept", "text/csv, */*;q=0.5")
+	start := time.Now()
+	resp, err := safefetch.NewClient(importing.Timeout).Do(req)
+	if err != nil {
+		var timeout interface{ Timeout() bool }
+		switch {
+		case errors.Is(err, safefetch.ErrForbiddenAddress):
+			respond.Error(w, "Invalid URL: it leads to an address that is not public", http.StatusBadRequest)
+		case errors.As(err, &timeout) && timeout.Timeout():
+			respond.Error(w, fmt.Sprintf("Download timed out after %s", importing.Timeout), http.StatusGatewayTimeout)
+		default:
+			respond.Error(w, fmt.Sprintf("Download failed: %v", err), http.StatusBadGateway)
+		}
+		return
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		respond.Error(w, fmt.Sprintf("Download failed: the server answered %s", resp.Status), http.StatusBadGateway)
+		return
+	}
+	if resp.ContentLength > importing.MaxBytes {
+		respond.Error(w, fmt.Sprintf("File too large: at most %d bytes", importing.MaxBytes), http.StatusRequestEntityTooLarge)
+		return
+	}
+
+	// Read one byte more than allowed, to tell a file of exactly MaxBytes from a larger one.
+	data, err := io.ReadAll(io.LimitReader(resp.Body, importing.MaxBytes+1))
+	if err != nil {
+		respond.Error(w, fmt.Sprintf("Download failed: %v", err), http.StatusBadGateway)
+		return
+	}
+	if int64(len(data)) > importing.MaxBytes {
+		respond.Error(w, fmt.Sprintf("File too large: at most %d bytes", importing.MaxBytes), http.StatusRequestEntityTooLarge)
+		return
+	}
+
+	books, ok := importBooks(w, r, db, "ImportBooksFromURL", data, bulk)
+	if !ok {
+		return
+	}
+	log.Printf("Imported %d books from %s in %s", len(books), source.Redacted(), time.Since(start))
+
+	respond.JSON(w, r, http.StatusCreated, books)
+}
+
+// ImportBooks handles importing the books of an uploaded CSV file.
+// @Summary Import books from a file
+// @Description Create the books of a CSV file uploaded as the file field of a multipart/form-data request, in
+// @Description a single transaction: either every book is created or none is. The file is read like the one of
+// @Description POST /books/import-url, with the same columns and limits, IMPORT_URL_MAX_BYTES included.
+// @Description Requires the admin API key.
+// @Tags books
+// @Accept multipart/form-data
+// @Produce json
+// @Security ApiKeyAuth
+// @Param file formData file true "CSV file"
+// @Success 201 {array} models.Book
+// @Failure 400 {string} string "Invalid upload or file"
+// @Failure 415 {string} string "Request body not sent as multipart/form-data"
+// @Failure 401 {string} string "Unauthorized"
+// @Failure 413 {string} string "File too large or with too many books"
+// @Failure 409 {string} string "A book with the ISBN, or the title and author when ENFORCE_UNIQUE_TITLE_AUTHOR is enabled, of another one"
+// @Router /books/import [post]
+func ImportBooks(w http.ResponseWriter, r *http.Request, db *sql.DB, importing config.Import, bulk config.Bulk) {
+	w.Header().Set("Content-Type", "application/json")
+
+	mediaType, _, _ := mime.ParseMediaType(r.Header.Get("Content-Type"))
+	if mediaType != "multipart/form-data" {
+		respond.Error(w, "Unsupported Content-Type: the file must be uploaded as multipart/form-data", http.StatusUnsupportedMediaType)
+		return
+	}
+	r.Body = http.MaxBytesReader(w, r.Body, importing.MaxBytes+multipartOverhead)
+	var tooLarge *http.MaxBytesError
+	file, _, err := r.FormFile("file")
+	switch {
+	case errors.As(err, &tooLarge):
+		respond.Error(w, fmt.Sprintf("File too large: at most %d bytes", importing.MaxBytes), http.StatusRequestEntityTooLarge)
+		return
+	case errors.Is(err, http.ErrMissingFile):
+		respond.Error(w, "Invalid upload: the file field is required", http.StatusBadRequest)
+		return
+	case err != nil:
+		respond.Error(w, fmt.Sprintf("Invalid upload: %v", err), http.StatusBadRequest)
+		return
+	}
+	defer file.Close()
+	defer r.MultipartForm.RemoveAll()
+
+	// Read one byte more than allowed, to tell a file of exactly MaxBytes from a larger one.
+	data, err := io.ReadAll(io.LimitReader(file, importing.MaxBytes+1))
+	if err != nil {
+		respond.Error(w, fmt.Sprintf("Invalid upload: %v", err), http.StatusBadRequest)
+		return
+	}
+	if int64(len(data)) > importing.MaxBytes {
+		respond.Error(w, fmt.Sprintf("File too large: at most %d bytes", importing.MaxBytes), http.StatusRequestEntityTooLarge)
+		return
+	}
+
+	books, ok := importBooks(w, r, db, "ImportBooks", data, bulk)
+	if !ok {
+		return
+	}
+	log.Printf("Imported %d books from an uploaded file", len(books))
+
+	respond.JSON(w, r, http.StatusCreated, books)
+}
+
+// multipartOverhead is the room left in an upload, besides the file, for the multipart boundaries
+// and part headers.
+const multipartOverhead = 64 << 10
+
+// importBooks creates the books of CSV data in a single transaction, for both import endpoints,
+// handler naming the one in the validation metrics. It answers the request and returns false when
+// the file is invalid or cannot be imported; the caller answers a successful import.
+func importBooks(w http.ResponseWriter, r *http.Request, db *sql.DB, handler string, data []byte, bulk config.Bulk) ([]models.Book, bool) {
+	books, err := parseBooksCSV(bytes.NewReader(data), bulk.MaxItems)
+	if errors.Is(err, errTooManyBooks) {
+		respond.Error(w, fmt.Sprintf("Too many books in the file: at most %d per import", bulk.MaxItems), http.StatusRequestEntityTooLarge)
+		return nil, false
+	}
+	if err != nil {
+		respond.Error(w, fmt.Sprintf("Invalid CSV file: %v", err), http.StatusBadRequest)
+		return nil, false
+	}
+	if len(books) == 0 {
+		respond.Error(w, "Invalid CSV file: at least one book is required", http.StatusBadRequest)
+		return nil, false
+	}
+
+	now := time.Now()
+	for i := range books {
+		if errs := validateNewBook(handler, &books[i], now); errs != nil {
+			respond.Error(w, fmt.Sprintf("Invalid CSV file: book %d: %s", i+1, errs.Error()), http.StatusBadRequest)
+			return nil, false
+		}
+	}
+
+	if err := insertBooks(r, db, books); err != nil {
+		// A book with the ISBN, or the title and author, of a stored one or of another book of the file.
+		if message, conflict := conflictMessage(err); conflict {
+			respond.Error(w, message, http.StatusConflict)
+			return nil, false
+		}
+		respond.Error(w, fmt.Sprintf("Database insert failed: %v", err), http.StatusInternalServerError)
+		return nil, false
+	}
+	return books, true
+}
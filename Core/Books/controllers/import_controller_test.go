@@ -0,0 +1,57 @@
+package controllers
+
+import (
+	"bytes"
+	"database/sql"
+	"golang-api-rest-swagger/Core/Shared/config"
+	"mime/multipart"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestImportBooksRejectsInvalidUploads(t *testing.T) {
+	// A nil database: the upload is checked before any query.
+	var db *sql.DB
+	importing := config.Import{MaxBytes: 64}
+	bulk := config.Bulk{MaxItems: 10}
+
+	multipartBody := func(field, content string) (string, *bytes.Buffer) {
+		var body bytes.Buffer
+		form := multipart.NewWriter(&body)
+		part, err := form.CreateFormFile(field, "books.json")
+		if err != nil {
+			t.Fatal(err)
+		}
+		part.Write([]byte(content))
+		form.Close()
+		return form.FormDataContentType(), &body
+	}
+
+	missingType, missingBody := multipartBody("upload", `[]`)
+	largeType, largeBody := multipartBody("file", strings.Repeat(" ", 128)+`[]`)
+	tests := []struct {
+		name        string
+		contentType string
+		body        *bytes.Buffer
+		wantStatus  int
+		want        string
+	}{
+		{"json body", "application/json", bytes.NewBufferString(`[{"title":"Dune"}]`), http.StatusUnsupportedMediaType, "multipart/form-data"},
+		{"no content type", "", bytes.NewBufferString(`[]`), http.StatusUnsupportedMediaType, "multipart/form-data"},
+		{"missing file field", missingType, missingBody, http.StatusBadRequest, "the file field is required"},
+		{"file too large", largeType, largeBody, http.StatusRequestEntityTooLarge, "File too large"},
+	}
+	for _, tt := range tests {
+		req := httptest.NewRequest("POST", "/books/import", tt.body)
+		if tt.contentType != "" {
+			req.Header.Set("Content-Type", tt.contentType)
+		}
+		rec := httptest.NewRecorder()
+		ImportBooks(rec, req, db, importing, bulk)
+		if rec.Code != tt.wantStatus || !strings.Contains(rec.Body.String(), tt.want) {
+			t.Errorf("%s: ImportBooks = %d %q, want %d %q", tt.name, rec.Code, rec.Body, tt.wantStatus, tt.want)
+		}
+	}
+}
@@ -0,0 +1,51 @@
+package controllers
+
+import (
+	"database/sql"
+	"fmt"
+	"golang-api-rest-swagger/Core/Books/database"
+	"golang-api-rest-swagger/Core/Books/models"
+	"golang-api-rest-swagger/Core/Shared/respond"
+	"net/http"
+)
+
+// GetBookIndex handles counting the books under each initial of their title.
+// @Summary Get the A-Z index of books
+// @Description Count the books by the first letter of their title, for alphabetical navigation. Titles that do
+// @Description not start with a letter from A to Z are grouped under #, listed last.
+// @Tags books
+// @Produce json
+// @Success 200 {array} models.LetterCount
+// @Router /books/index [get]
+func GetBookIndex(w http.ResponseWriter, r *http.Request, db *sql.DB) {
+	w.Header().Set("Content-Type", "application/json")
+
+	rows, err := db.QueryContext(r.Context(), fmt.Sprintf(
+		"SELECT CASE WHEN UPPER(LEFT(`title`, 1)) BETWEEN 'A' AND 'Z' THEN UPPER(LEFT(`title`, 1)) ELSE '#' END AS `letter`, "+
+			"COUNT(*) "+
+			"FROM %s "+
+			"WHERE `deleted_at` IS NULL AND `archived_at` IS NULL "+
+			"GROUP BY `letter` "+
+			"ORDER BY `letter` = '#', `letter`", database.Table(database.Books)))
+	if err != nil {
+		respond.Error(w, fmt.Sprintf("Database query failed: %v", err), http.StatusInternalServerError)
+		return
+	}
+	defer rows.Close()
+
+	letters := []models.LetterCount{}
+	for rows.Next() {
+		var letter models.LetterCount
+		if err := rows.Scan(&letter.Letter, &letter.Count); err != nil {
+			respond.Error(w, fmt.Sprintf("Failed to scan row: %v", err), http.StatusInternalServerError)
+			return
+		}
+		letters = append(letters, letter)
+	}
+	if err := rows.Err(); err != nil {
+		respond.Error(w, fmt.Sprintf("Error during row iteration: %v", err), http.StatusInternalServerError)
+		return
+	}
+
+	respond.JSON(w, r, http.StatusOK, letters)
+}
@@ -0,0 +1,36 @@
+package controllers
+
+import (
+	"fmt"
+	"golang-api-rest-swagger/Core/Books/models"
+	"net/http"
+)
+
+// formatMARC is the ?format= of books as MARC records, for library systems.
+const formatMARC = "marc-json"
+
+// wantsMARC reports whether the client of GetBook asked for a MARC record with ?format=marc-json.
+func wantsMARC(r *http.Request) (bool, error) {
+	switch format := r.URL.Query().Get("format"); format {
+	case formatMARC:
+		return true, nil
+	case "", "json":
+		return false, nil
+	default:
+		return false, fmt.Errorf("format must be json or marc-json")
+	}
+}
+
+// renderMARC renders a book as a MARC record, like projection.render for the other formats.
+func renderMARC(book models.Book) (any, error) {
+	return models.NewMARCRecord(book), nil
+}
+
+// marcRecords maps books to their MARC records.
+func marcRecords(books []models.Book) []models.MARCRecord {
+	records := make([]models.MARCRecord, len(books))
+	for i, book := range books {
+		records[i] = models.NewMARCRecord(book)
+	}
+	return records
+}
@@ -0,0 +1,32 @@
+package controllers
+
+import (
+	"database/sql"
+	"fmt"
+	"golang-api-rest-swagger/Core/Books/models"
+	"golang-api-rest-swagger/Core/Shared/respond"
+	"net/http"
+	"strings"
+)
+
+// wantsNDJSON reports whether the client asked for a newline delimited JSON stream,
+// either with ?format=ndjson or with an Accept: application/x-ndjson header.
+func wantsNDJSON(r *http.Request) (bool, error) {
+	switch format := r.URL.Query().Get("format"); format {
+	case "ndjson":
+		return true, nil
+	case "json", formatMARC:
+		return false, nil
+	case "":
+		return strings.Contains(r.Header.Get("Accept"), respond.NDJSONContentType), nil
+	default:
+		return false, fmt.Errorf("format must be json, ndjson or marc-json")
+	}
+}
+
+// streamBooks writes the books of rows, selected with the SELECT list of p and rendered with
+// render, as a newline delimited JSON stream, one book per line, without holding the result set
+// in memory.
+func streamBooks(w http.ResponseWriter, r *http.Request, rows *sql.Rows, p projection, render func(models.Book) (any, error)) {
+	writeBookStream(respond.NewNDJSONStream(w, r), rows, p, render)
+}
@@ -0,0 +1,90 @@
+package controllers
+
+import (
+	"fmt"
+	"golang-api-rest-swagger/Core/Shared/config"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+)
+
+// defaultPageSize is used when a request is paginated but does not specify a limit.
+const defaultPageSize = 20
+
+// Pagination holds the page requested by the client.
+type Pagination struct {
+	Page  int
+	Limit int
+}
+
+// Offset returns the number of rows to skip to reach the requested page.
+func (p Pagination) Offset() int {
+	return (p.Page - 1) * p.Limit
+}
+
+// parsePagination reads the page and limit query parameters.
+// It returns nil when neither parameter is present, meaning the request is not paginated.
+// A limit above listing.MaxPageSize is clamped to it, or rejected when listing.RejectOversizedPages is set.
+func parsePagination(r *http.Request, listing config.Listing) (*Pagination, error) {
+	query := r.URL.Query()
+	if !query.Has("page") && !query.Has("limit") {
+		return nil, nil
+	}
+
+	p := &Pagination{Page: 1, Limit: min(defaultPageSize, listing.MaxPageSize)}
+	if v := query.Get("page"); v != "" {
+		page, err := strconv.Atoi(v)
+		if err != nil || page < 1 {
+			return nil, fmt.Errorf("page must be a positive integer")
+		}
+		p.Page = page
+	}
+	if v := query.Get("limit"); v != "" {
+		limit, err := strconv.Atoi(v)
+		if err != nil || limit < 1 {
+			return nil, fmt.Errorf("limit must be a positive integer")
+		}
+		p.Limit = limit
+	}
+	if p.Limit > listing.MaxPageSize {
+		if listing.RejectOversizedPages {
+			return nil, fmt.Errorf("limit must be at most %d", listing.MaxPageSize)
+		}
+		p.Limit = listing.MaxPageSize
+	}
+	return p, nil
+}
+
+// setPaginationLinks sets a Link header (RFC 8288) with the self, first, last, prev and next pages,
+// as absolute URLs keeping every other query parameter of the request. prev and next are left out
+// on the first and last pages.
+func setPaginationLinks(w http.ResponseWriter, r *http.Request, p *Pagination, total int) {
+	last := max(1, (total+p.Limit-1)/p.Limit)
+	link := func(rel string, page int) string {
+		query := r.URL.Query()
+		query.Set("page", strconv.Itoa(page))
+		query.Set("limit", strconv.Itoa(p.Limit))
+		return fmt.Sprintf(`<%s>; rel="%s"`, absoluteURL(r, r.URL.Path, query.Encode()), rel)
+	}
+
+	links := []string{link("self", p.Page), link("first", 1), link("last", last)}
+	if p.Page > 1 {
+		links = append(links, link("prev", min(p.Page-1, last)))
+	}
+	if p.Page < last {
+		links = append(links, link("next", p.Page+1))
+	}
+	w.Header().Set("Link", strings.Join(links, ", "))
+}
+
+// absoluteURL returns the absolute URL of path, with the raw query string query, on the host the
+// request was sent to.
+func absoluteURL(r *http.Request, path, query string) string {
+	scheme := "http"
+	if r.TLS != nil {
+		scheme = "https"
+	}
+	u := url.URL{Scheme: scheme, Host: r.Host, Path: path, RawQuery: query}
+	return u.String()
+}
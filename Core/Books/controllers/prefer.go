@@ -0,0 +1,22 @@
+package controllers
+
+import (
+	"net/http"
+	"strings"
+)
+
+// prefersMinimal reports whether the request asks, with the Prefer header of RFC 7240, for a
+// response without the representation of the resource: Prefer: return=minimal.
+func prefersMinimal(r *http.Request) bool {
+	for _, header := range r.Header.Values("Prefer") {
+		for _, preference := range strings.Split(header, ",") {
+			// Parameters of the preference, after a semicolon, do not change its meaning here.
+			preference, _, _ = strings.Cut(preference, ";")
+			name, value, _ := strings.Cut(strings.TrimSpace(preference), "=")
+			if strings.EqualFold(name, "return") && strings.EqualFold(strings.Trim(value, `" `), "minimal") {
+				return true
+			}
+		}
+	}
+	return false
+}
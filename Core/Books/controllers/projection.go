@@ -0,0 +1,192 @@
+package controllers
+
+import (
+	"bytes"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"golang-api-rest-swagger/Core/Books/models"
+	"golang-api-rest-swagger/Core/Shared/timestamp"
+	"golang-api-rest-swagger/Core/Shared/visibility"
+	"reflect"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// bookColumn is a field of a book a list can be projected on: its JSON name, the expression
+// selecting it, the models.Book field it is read from and visible through, and where to scan it.
+type bookColumn struct {
+	name   string
+	column string
+	field  string
+	dest   func(*models.Book) any
+}
+
+// bookColumns are the fields of a book in the order of models.Book, which is the order of the
+// SELECT list and of the keys of a projected book. Only these expressions ever reach the SELECT.
+var bookColumns = []bookColumn{
+	{name: "id", column: "`id`", field: "ID", dest: func(b *models.Book) any { return &b.ID }},
+	{name: "title", column: "`title`", field: "Title", dest: func(b *models.Book) any { return &b.Title }},
+	{name: "author", column: "`author`", field: "Author", dest: func(b *models.Book) any { return &b.Author }},
+	{name: "year", column: "`publication_year`", field: "Year", dest: func(b *models.Book) any { return &b.Year }},
+	{name: "cover_url", column: "`cover_url`", field: "CoverURL", dest: func(b *models.Book) any { return &b.CoverURL }},
+	{name: "notes", column: "`notes`", field: "Notes", dest: func(b *models.Book) any { return &b.Notes }},
+	{name: "isbn", column: "COALESCE(`isbn`, '')", field: "ISBN", dest: func(b *models.Book) any { return &b.ISBN }},
+	{name: "created_at", column: "`created_at`", field: "CreatedAt", dest: func(b *models.Book) any { return timestampDest(&b.CreatedAt) }},
+	{name: "updated_at", column: "`updated_at`", field: "UpdatedAt", dest: func(b *models.Book) any { return timestampDest(&b.UpdatedAt) }},
+}
+
+// timestampDest allocates the timestamp t points to, when needed, and returns where to scan it.
+func timestampDest(t **timestamp.Time) *time.Time {
+	if *t == nil {
+		*t = &timestamp.Time{}
+	}
+	return &(*t).Time
+}
+
+// hiddenFieldError reports a field asked for in ?fields= that the caller may not see.
+type hiddenFieldError struct {
+	name string
+}
+
+func (e hiddenFieldError) Error() string {
+	return fmt.Sprintf("the %s field requires the admin API key", e.name)
+}
+
+// projection is the fields of the books a list returns, every one when nil. The SELECT only
+// reads the columns of the projection, so narrow lists also spare the database and the network.
+type projection []bookColumn
+
+// parseProjection turns the comma separated field names of ?fields= into a projection. The id is
+// always part of it, as shape=map keys the books by id. role is that of the caller, who may not
+// ask for the fields hidden from it.
+func parseProjection(spec, role string) (projection, error) {
+	if spec == "" {
+		return nil, nil
+	}
+	selected := map[string]bool{"id": true}
+	for _, name := range strings.Split(spec, ",") {
+		name = strings.TrimSpace(name)
+		i := slices.IndexFunc(bookColumns, func(column bookColumn) bool { return column.name == name })
+		if i < 0 {
+			return nil, fmt.Errorf("fields has an unknown field %q, expected id, title, author, year, cover_url, notes, isbn, created_at or updated_at", name)
+		}
+		field, _ := reflect.TypeOf(models.Book{}).FieldByName(bookColumns[i].field)
+		if !visibility.Visible(field, role) {
+			return nil, hiddenFieldError{name: name}
+		}
+		selected[name] = true
+	}
+	var p projection
+	for _, column := range bookColumns {
+		if selected[column.name] {
+			p = append(p, column)
+		}
+	}
+	return p, nil
+}
+
+// columns returns the projected columns, every one when p is nil.
+func (p projection) columns() []bookColumn {
+	if p == nil {
+		return bookColumns
+	}
+	return p
+}
+
+// selectList returns the SELECT list of the projected columns.
+func (p projection) selectList() string {
+	columns := p.columns()
+	list := make([]string, len(columns))
+	for i, column := range columns {
+		list[i] = column.column
+	}
+	return strings.Join(list, ", ")
+}
+
+// query returns the SELECT of the projected columns of the books of table matching where, in the
+// given order. Every page of a list, whether skipped to with OFFSET or sought by id, reads the
+// same columns.
+func (p projection) query(table, where, order string) string {
+	return fmt.Sprintf("SELECT %s FROM %s%s ORDER BY %s", p.selectList(), table, where, order)
+}
+
+// scan reads a book from a row selected with selectList. The fields outside the projection are
+// left empty.
+func (p projection) scan(rows *sql.Rows) (models.Book, error) {
+	var book models.Book
+	err := rows.Scan(p.dests(&book)...)
+	return book, err
+}
+
+// dests returns the scan destinations of the projected columns in book.
+func (p projection) dests(book *models.Book) []any {
+	columns := p.columns()
+	dest := make([]any, len(columns))
+	for i, column := range columns {
+		dest[i] = column.dest(book)
+	}
+	return dest
+}
+
+// render returns the response body of a scanned book: the book itself without a projection, or
+// an object holding only the projected fields.
+func (p projection) render(book models.Book) (any, error) {
+	if p == nil {
+		return book, nil
+	}
+	return p.project(book)
+}
+
+// list returns the response body of a list of books, an array or, when keyed, an object of the
+// books by id. Without a projection the books keep their type, which the response filters the
+// fields hidden from the caller out of.
+func (p projection) list(books []models.Book, keyed bool) (any, error) {
+	if p == nil && !keyed {
+		return books, nil
+	}
+	if p == nil {
+		byID := make(map[string]models.Book, len(books))
+		for _, book := range books {
+			byID[strconv.FormatInt(book.ID, 10)] = book
+		}
+		return byID, nil
+	}
+	projected := make([]json.RawMessage, len(books))
+	for i, book := range books {
+		var err error
+		if projected[i], err = p.project(book); err != nil {
+			return nil, err
+		}
+	}
+	if !keyed {
+		return projected, nil
+	}
+	byID := make(map[string]json.RawMessage, len(books))
+	for i, book := range books {
+		byID[strconv.FormatInt(book.ID, 10)] = projected[i]
+	}
+	return byID, nil
+}
+
+// project returns book as an object holding only the projected fields, in the order of bookColumns.
+func (p projection) project(book models.Book) (json.RawMessage, error) {
+	var buf bytes.Buffer
+	buf.WriteByte('{')
+	for i, column := range p {
+		if i > 0 {
+			buf.WriteByte(',')
+		}
+		// The field itself rather than its scan destination, so timestamps follow the TIME_FORMAT.
+		value, err := json.Marshal(reflect.ValueOf(book).FieldByName(column.field).Interface())
+		if err != nil {
+			return nil, err
+		}
+		fmt.Fprintf(&buf, "%q:", column.name)
+		buf.Write(value)
+	}
+	buf.WriteByte('}')
+	return json.RawMessage(buf.Bytes()), nil
+}
@@ -0,0 +1,57 @@
+package controllers
+
+import (
+	"errors"
+	"golang-api-rest-swagger/Core/Shared/auth"
+	"testing"
+)
+
+func TestParseProjectionSelectList(t *testing.T) {
+	tests := []struct {
+		spec string
+		role string
+		want string
+	}{
+		{"", "", "`id`, `title`, `author`, `publication_year`, `cover_url`, `notes`, COALESCE(`isbn`, ''), `created_at`, `updated_at`"},
+		{"title,author", "", "`id`, `title`, `author`"},
+		{"author,title", "", "`id`, `title`, `author`"},
+		{" year , title ", "", "`id`, `title`, `publication_year`"},
+		{"id", "", "`id`"},
+		{"title,title", "", "`id`, `title`"},
+		{"isbn", "", "`id`, COALESCE(`isbn`, '')"},
+		{"title,notes", auth.RoleAdmin, "`id`, `title`, `notes`"},
+	}
+	for _, tt := range tests {
+		p, err := parseProjection(tt.spec, tt.role)
+		if err != nil {
+			t.Errorf("parseProjection(%q, %q) failed: %v", tt.spec, tt.role, err)
+			continue
+		}
+		if got := p.selectList(); got != tt.want {
+			t.Errorf("parseProjection(%q, %q).selectList() = %s, want %s", tt.spec, tt.role, got, tt.want)
+		}
+	}
+}
+
+func TestParseProjectionRejectsUnknownFields(t *testing.T) {
+	for _, spec := range []string{"price", "title,price", "publication_year", "`title`", "title;DROP TABLE books", "title,"} {
+		p, err := parseProjection(spec, auth.RoleAdmin)
+		if err == nil {
+			t.Errorf("parseProjection(%q) = %s, want an error", spec, p.selectList())
+			continue
+		}
+		if errors.As(err, &hiddenFieldError{}) {
+			t.Errorf("parseProjection(%q) failed with %v, want an unknown field error", spec, err)
+		}
+	}
+}
+
+func TestParseProjectionHidesNotesFromNonAdmins(t *testing.T) {
+	for _, role := range []string{"", auth.RoleUser} {
+		_, err := parseProjection("title,notes", role)
+		var hidden hiddenFieldError
+		if !errors.As(err, &hidden) || hidden.name != "notes" {
+			t.Errorf("parseProjection(\"title,notes\", %q) failed with %v, want a hiddenFieldError for notes", role, err)
+		}
+	}
+}
@@ -0,0 +1,59 @@
+package controllers
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"golang-api-rest-swagger/Core/Shared/debugsql"
+)
+
+// seekComparisons are the orders a page can be read by seeking, those by id alone, with the
+// comparison selecting the books from the first one of the page on.
+var seekComparisons = map[string]string{
+	"`id` ASC":  ">=",
+	"`id` DESC": "<=",
+}
+
+// boundaryFunc runs query, returning the id it selects and false when it selects no row.
+type boundaryFunc func(query string, args []any) (int64, bool, error)
+
+// listQuery returns the query reading the books of table matching filter in the given order,
+// with its arguments: the page, or every book when page is nil. A deep page of a list sorted by
+// id alone and filtered by its scope alone is read from its first book, whose id boundary finds,
+// instead of with OFFSET. Any other filter would make the boundary query read the rows it skips.
+func listQuery(fields projection, table string, filter *bookFilter, order string, page *Pagination, deep bool, boundary boundaryFunc) (string, []any, error) {
+	query := fields.query(table, filter.where(), order)
+	args := filter.arguments()
+	if page == nil {
+		return query, args, nil
+	}
+	comparison, seekable := seekComparisons[order]
+	if !deep || !seekable || !filter.scopeOnly() {
+		return query + " LIMIT ? OFFSET ?", append(args, page.Limit, page.Offset()), nil
+	}
+	id, found, err := boundary(fmt.Sprintf("SELECT `id` FROM %s%s ORDER BY %s LIMIT 1 OFFSET ?", table, filter.where(), order), append(filter.arguments(), page.Offset()))
+	if err != nil {
+		return "", nil, err
+	}
+	if !found {
+		// The list shrank since it was counted: the page is past its end.
+		return query + " LIMIT 0", args, nil
+	}
+	return fields.query(table, filter.whereAnd("`id` "+comparison+" ?"), order) + " LIMIT ?", append(args, id, page.Limit), nil
+}
+
+// seekBoundary returns the boundaryFunc of listQuery running its query on db. The query only
+// reads the idx_books_scope index, so MySQL skips the previous books there instead of walking the
+// clustered index and its whole rows. The index holds the ids of active books in order; those of
+// archived ones are sorted as read.
+func seekBoundary(ctx context.Context, db *sql.DB) boundaryFunc {
+	return func(query string, args []any) (int64, bool, error) {
+		debugsql.Record(ctx, query, args...)
+		var id int64
+		err := db.QueryRowContext(ctx, query, args...).Scan(&id)
+		if err == sql.ErrNoRows {
+			return 0, false, nil
+		}
+		return id, err == nil, err
+	}
+}
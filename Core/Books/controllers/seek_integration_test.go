@@ -0,0 +1,87 @@
+//go:build integration
+
+package controllers
+
+import (
+	"database/sql"
+	"fmt"
+	"golang-api-rest-swagger/Core/Books/database"
+	"golang-api-rest-swagger/Core/Books/database/dbtest"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+// explain returns the rows of EXPLAIN query, by column name.
+func explain(t *testing.T, db *sql.DB, query string, args []any) []map[string]string {
+	t.Helper()
+	rows, err := db.Query("EXPLAIN "+query, args...)
+	if err != nil {
+		t.Fatalf("EXPLAIN %s: %v", query, err)
+	}
+	defer rows.Close()
+	columns, err := rows.Columns()
+	if err != nil {
+		t.Fatal(err)
+	}
+	var plan []map[string]string
+	for rows.Next() {
+		values := make([]sql.NullString, len(columns))
+		dest := make([]any, len(columns))
+		for i := range values {
+			dest[i] = &values[i]
+		}
+		if err := rows.Scan(dest...); err != nil {
+			t.Fatal(err)
+		}
+		row := map[string]string{}
+		for i, column := range columns {
+			row[column] = values[i].String
+		}
+		plan = append(plan, row)
+	}
+	if err := rows.Err(); err != nil {
+		t.Fatal(err)
+	}
+	return plan
+}
+
+func TestSeekBoundaryOnlyReadsTheScopeIndex(t *testing.T) {
+	_, pools := dbtest.Open(t)
+	db := pools.Primary
+	table := database.Table(database.Books)
+	for i := 0; i < 500; i++ {
+		if _, err := db.Exec(fmt.Sprintf("INSERT INTO %s (`title`, `author`, `publication_year`) VALUES (?, ?, ?)", table), fmt.Sprintf("Book %d", i), "Author", 2000); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if _, err := db.Exec(fmt.Sprintf("UPDATE %s SET `deleted_at` = NOW() WHERE `id` %% 7 = 0", table)); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := db.Exec(fmt.Sprintf("ANALYZE TABLE %s", table)); err != nil {
+		t.Fatal(err)
+	}
+
+	for _, order := range []string{"`id` ASC", "`id` DESC"} {
+		filter, err := parseBookFilter(httptest.NewRequest("GET", "/books", nil))
+		if err != nil {
+			t.Fatal(err)
+		}
+		ran := false
+		boundary := func(query string, args []any) (int64, bool, error) {
+			ran = true
+			for _, row := range explain(t, db, query, args) {
+				if row["key"] != "idx_books_scope" || !strings.Contains(row["Extra"], "Using index") || strings.Contains(row["Extra"], "filesort") {
+					t.Errorf("EXPLAIN %s = %v, want an index only read of idx_books_scope", query, row)
+				}
+			}
+			return seekBoundary(t.Context(), db)(query, args)
+		}
+		if _, _, err := listQuery(nil, table, filter, order, &Pagination{Page: 20, Limit: 10}, true, boundary); err != nil {
+			t.Fatal(err)
+		}
+		if !ran {
+			t.Errorf("order %s: the page was not sought", order)
+		}
+	}
+}
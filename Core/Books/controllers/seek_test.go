@@ -0,0 +1,134 @@
+package controllers
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+)
+
+func TestListQuery(t *testing.T) {
+	fields, err := parseProjection("title,author", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	// Only lists filtered by their scope alone can seek, the others use OFFSET.
+	scoped := &bookFilter{scope: []string{"`deleted_at` IS NULL", "`archived_at` IS NULL"}}
+	filtered := &bookFilter{scope: scoped.scope}
+	filtered.add("`author` IN (?)", "Tolkien")
+	tests := []struct {
+		name   string
+		filter *bookFilter
+		order  string
+		page   *Pagination
+		deep   bool
+		// found is what the boundary query returns, when it runs.
+		found     bool
+		wantQuery string
+		wantArgs  []any
+		// wantBoundary is the boundary query expected to run, empty when none is.
+		wantBoundary string
+	}{
+		{
+			name:      "unpaginated",
+			filter:    filtered,
+			order:     "`id` ASC",
+			wantQuery: "SELECT `id`, `title`, `author` FROM `books` WHERE `deleted_at` IS NULL AND `archived_at` IS NULL AND (`author` IN (?)) ORDER BY `id` ASC",
+			wantArgs:  []any{"Tolkien"},
+		},
+		{
+			name:      "offset",
+			filter:    filtered,
+			order:     "`id` ASC",
+			page:      &Pagination{Page: 3, Limit: 10},
+			wantQuery: "SELECT `id`, `title`, `author` FROM `books` WHERE `deleted_at` IS NULL AND `archived_at` IS NULL AND (`author` IN (?)) ORDER BY `id` ASC LIMIT ? OFFSET ?",
+			wantArgs:  []any{"Tolkien", 10, 20},
+		},
+		{
+			name:      "deep page in an order that cannot seek",
+			filter:    scoped,
+			order:     "`title` ASC, `id` ASC",
+			page:      &Pagination{Page: 3, Limit: 10},
+			deep:      true,
+			wantQuery: "SELECT `id`, `title`, `author` FROM `books` WHERE `deleted_at` IS NULL AND `archived_at` IS NULL ORDER BY `title` ASC, `id` ASC LIMIT ? OFFSET ?",
+			wantArgs:  []any{10, 20},
+		},
+		{
+			name:      "deep page of a filtered list",
+			filter:    filtered,
+			order:     "`id` ASC",
+			page:      &Pagination{Page: 3, Limit: 10},
+			deep:      true,
+			wantQuery: "SELECT `id`, `title`, `author` FROM `books` WHERE `deleted_at` IS NULL AND `archived_at` IS NULL AND (`author` IN (?)) ORDER BY `id` ASC LIMIT ? OFFSET ?",
+			wantArgs:  []any{"Tolkien", 10, 20},
+		},
+		{
+			name:         "seek",
+			filter:       scoped,
+			order:        "`id` ASC",
+			page:         &Pagination{Page: 3, Limit: 10},
+			deep:         true,
+			found:        true,
+			wantQuery:    "SELECT `id`, `title`, `author` FROM `books` WHERE `deleted_at` IS NULL AND `archived_at` IS NULL AND `id` >= ? ORDER BY `id` ASC LIMIT ?",
+			wantArgs:     []any{int64(42), 10},
+			wantBoundary: "SELECT `id` FROM `books` WHERE `deleted_at` IS NULL AND `archived_at` IS NULL ORDER BY `id` ASC LIMIT 1 OFFSET ?",
+		},
+		{
+			name:         "seek descending",
+			filter:       scoped,
+			order:        "`id` DESC",
+			page:         &Pagination{Page: 3, Limit: 10},
+			deep:         true,
+			found:        true,
+			wantQuery:    "SELECT `id`, `title`, `author` FROM `books` WHERE `deleted_at` IS NULL AND `archived_at` IS NULL AND `id` <= ? ORDER BY `id` DESC LIMIT ?",
+			wantArgs:     []any{int64(42), 10},
+			wantBoundary: "SELECT `id` FROM `books` WHERE `deleted_at` IS NULL AND `archived_at` IS NULL ORDER BY `id` DESC LIMIT 1 OFFSET ?",
+		},
+		{
+			name:         "seek past the end",
+			filter:       scoped,
+			order:        "`id` ASC",
+			page:         &Pagination{Page: 3, Limit: 10},
+			deep:         true,
+			wantQuery:    "SELECT `id`, `title`, `author` FROM `books` WHERE `deleted_at` IS NULL AND `archived_at` IS NULL ORDER BY `id` ASC LIMIT 0",
+			wantArgs:     []any{},
+			wantBoundary: "SELECT `id` FROM `books` WHERE `deleted_at` IS NULL AND `archived_at` IS NULL ORDER BY `id` ASC LIMIT 1 OFFSET ?",
+		},
+	}
+	for _, tt := range tests {
+		var ranBoundary string
+		var boundaryArgs []any
+		boundary := func(query string, args []any) (int64, bool, error) {
+			ranBoundary, boundaryArgs = query, args
+			if !tt.found {
+				return 0, false, nil
+			}
+			return 42, true, nil
+		}
+		query, args, err := listQuery(fields, "`books`", tt.filter, tt.order, tt.page, tt.deep, boundary)
+		if err != nil {
+			t.Errorf("%s: listQuery failed: %v", tt.name, err)
+			continue
+		}
+		if query != tt.wantQuery {
+			t.Errorf("%s: query = %s, want %s", tt.name, query, tt.wantQuery)
+		}
+		if !reflect.DeepEqual(args, tt.wantArgs) {
+			t.Errorf("%s: args = %v, want %v", tt.name, args, tt.wantArgs)
+		}
+		if ranBoundary != tt.wantBoundary {
+			t.Errorf("%s: boundary query = %q, want %q", tt.name, ranBoundary, tt.wantBoundary)
+		}
+		if tt.wantBoundary != "" && !reflect.DeepEqual(boundaryArgs, []any{20}) {
+			t.Errorf("%s: boundary args = %v, want [20]", tt.name, boundaryArgs)
+		}
+	}
+}
+
+func TestListQueryBoundaryError(t *testing.T) {
+	failure := errors.New("connection lost")
+	boundary := func(string, []any) (int64, bool, error) { return 0, false, failure }
+	_, _, err := listQuery(nil, "`books`", &bookFilter{}, "`id` ASC", &Pagination{Page: 2, Limit: 10}, true, boundary)
+	if !errors.Is(err, failure) {
+		t.Errorf("listQuery error = %v, want %v", err, failure)
+	}
+}
@@ -0,0 +1,88 @@
+package controllers
+
+import (
+	"database/sql"
+	"fmt"
+	"github.com/gorilla/mux"
+	"golang-api-rest-swagger/Core/Books/database"
+	"golang-api-rest-swagger/Core/Books/models"
+	"golang-api-rest-swagger/Core/Shared/config"
+	"golang-api-rest-swagger/Core/Shared/respond"
+	"net/http"
+	"strconv"
+)
+
+// defaultSimilarLimit is the number of recommendations returned when no limit is given.
+const defaultSimilarLimit = 5
+
+// GetSimilarBooks handles recommending books related to a given one.
+// @Summary Find similar books
+// @Description List other books by the same author or published in the same decade as the given book.
+// @Description Books by the same author come first, then the closest years.
+// @Tags books
+// @Produce json
+// @Param id path int true "Book ID" example(1)
+// @Param limit query int false "Number of books to return, at most MAX_PAGE_SIZE" default(5)
+// @Param page query int false "Page number, starting at 1" example(1)
+// @Success 200 {array} models.Book
+// @Failure 400 {string} string "Invalid book ID"
+// @Failure 404 {string} string "Book not found"
+// @Router /books/{id}/similar [get]
+func GetSimilarBooks(w http.ResponseWriter, r *http.Request, db *sql.DB, listing config.Listing) {
+	w.Header().Set("Content-Type", "application/json")
+	id, err := strconv.ParseInt(mux.Vars(r)["id"], 10, 64)
+	if err != nil {
+		respond.Error(w, "Invalid book ID", http.StatusBadRequest)
+		return
+	}
+
+	pagination, err := parsePagination(r, listing)
+	if err != nil {
+		respond.Error(w, fmt.Sprintf("Invalid pagination parameters: %v", err), http.StatusBadRequest)
+		return
+	}
+	if pagination == nil {
+		pagination = &Pagination{Page: 1, Limit: min(defaultSimilarLimit, listing.MaxPageSize)}
+	}
+
+	table := database.Table(database.Books)
+	var base models.Book
+	err = db.QueryRowContext(r.Context(), fmt.Sprintf("SELECT `id`, `title`, `author`, `publication_year`, `cover_url`, `notes`, COALESCE(`isbn`, '') FROM %s WHERE `id` = ? AND `deleted_at` IS NULL", table), id).
+		Scan(&base.ID, &base.Title, &base.Author, &base.Year, &base.CoverURL, &base.Notes, &base.ISBN)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			respond.Error(w, "Book not found", http.StatusNotFound)
+			return
+		}
+		respond.Error(w, fmt.Sprintf("Database query failed: %v", err), http.StatusInternalServerError)
+		return
+	}
+
+	rows, err := db.QueryContext(r.Context(), fmt.Sprintf(
+		"SELECT `id`, `title`, `author`, `publication_year`, `cover_url`, `notes`, COALESCE(`isbn`, '') FROM %s "+
+			"WHERE `deleted_at` IS NULL AND `archived_at` IS NULL AND `id` <> ? AND (`author` = ? OR `publication_year` DIV 10 = ? DIV 10) "+
+			"ORDER BY `author` = ? DESC, ABS(`publication_year` - ?), `id` "+
+			"LIMIT ? OFFSET ?", table),
+		base.ID, base.Author, base.Year, base.Author, base.Year, pagination.Limit, pagination.Offset())
+	if err != nil {
+		respond.Error(w, fmt.Sprintf("Database query failed: %v", err), http.StatusInternalServerError)
+		return
+	}
+	defer rows.Close()
+
+	books := []models.Book{}
+	for rows.Next() {
+		var book models.Book
+		if err := rows.Scan(&book.ID, &book.Title, &book.Author, &book.Year, &book.CoverURL, &book.Notes, &book.ISBN); err != nil {
+			respond.Error(w, fmt.Sprintf("Failed to scan row: %v", err), http.StatusInternalServerError)
+			return
+		}
+		books = append(books, book)
+	}
+	if err := rows.Err(); err != nil {
+		respond.Error(w, fmt.Sprintf("Error during row iteration: %v", err), http.StatusInternalServerError)
+		return
+	}
+
+	respond.JSON(w, r, http.StatusOK, books)
+}
@@ -0,0 +1,70 @@
+package controllers
+
+import (
+	"fmt"
+	"strings"
+)
+
+// sortColumns are the fields the book list can be sorted by, with their quoted column. Only these
+// columns ever reach the ORDER BY clause.
+var sortColumns = map[string]string{
+	"id":         "`id`",
+	"title":      "`title`",
+	"author":     "`author`",
+	"year":       "`publication_year`",
+	"created_at": "`created_at`",
+}
+
+// defaultOrder is the ORDER BY clause of book lists requested without ?sort=, set by SetDefaultSort.
+var defaultOrder = "`id` ASC"
+
+// SetDefaultSort sets the order of book lists requested without ?sort=, in the same syntax, e.g.
+// -created_at for newest first. It is meant to be called once at startup, before serving.
+func SetDefaultSort(spec string) error {
+	if spec == "" {
+		return nil
+	}
+	order, err := parseSort(spec)
+	if err != nil {
+		return err
+	}
+	defaultOrder = order
+	return nil
+}
+
+// parseSort turns a comma separated list of fields, each descending when prefixed with -, into an
+// ORDER BY clause, e.g. "author,-year". The id is always the last key, so books with equal values
+// keep a deterministic order and page boundaries do not move between requests.
+func parseSort(spec string) (string, error) {
+	var keys []string
+	seen := map[string]bool{}
+	for _, field := range strings.Split(spec, ",") {
+		field = strings.TrimSpace(field)
+		direction := "ASC"
+		if name, ok := strings.CutPrefix(field, "-"); ok {
+			field, direction = name, "DESC"
+		}
+		column, ok := sortColumns[field]
+		if !ok {
+			return "", fmt.Errorf("sort has an unknown field %q, expected id, title, author, year or created_at", field)
+		}
+		if seen[field] {
+			return "", fmt.Errorf("sort has the field %s more than once", field)
+		}
+		seen[field] = true
+		keys = append(keys, column+" "+direction)
+	}
+	if !seen["id"] {
+		keys = append(keys, "`id` ASC")
+	}
+	return strings.Join(keys, ", "), nil
+}
+
+// bookOrder returns the ORDER BY clause of a book list: that of ?sort= when given, the default one
+// otherwise.
+func bookOrder(sort string) (string, error) {
+	if sort == "" {
+		return defaultOrder, nil
+	}
+	return parseSort(sort)
+}
@@ -0,0 +1,71 @@
+package controllers
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestParseSort(t *testing.T) {
+	tests := []struct {
+		spec string
+		want string
+	}{
+		{"title", "`title` ASC, `id` ASC"},
+		{"-year", "`publication_year` DESC, `id` ASC"},
+		{"author,-year", "`author` ASC, `publication_year` DESC, `id` ASC"},
+		{" title , -created_at ", "`title` ASC, `created_at` DESC, `id` ASC"},
+		{"-id", "`id` DESC"},
+		{"title,id", "`title` ASC, `id` ASC"},
+	}
+	for _, tt := range tests {
+		got, err := parseSort(tt.spec)
+		if err != nil {
+			t.Errorf("parseSort(%q) failed: %v", tt.spec, err)
+			continue
+		}
+		if got != tt.want {
+			t.Errorf("parseSort(%q) = %s, want %s", tt.spec, got, tt.want)
+		}
+	}
+}
+
+func TestParseSortOnlyQuotedWhitelistedColumns(t *testing.T) {
+	allowed := map[string]bool{}
+	for _, column := range sortColumns {
+		allowed[column] = true
+	}
+	for field := range sortColumns {
+		for _, spec := range []string{field, "-" + field} {
+			order, err := parseSort(spec)
+			if err != nil {
+				t.Fatalf("parseSort(%q) failed: %v", spec, err)
+			}
+			for _, key := range strings.Split(order, ", ") {
+				column, direction, _ := strings.Cut(key, " ")
+				if !allowed[column] || !strings.HasPrefix(column, "`") || !strings.HasSuffix(column, "`") {
+					t.Errorf("parseSort(%q) produced the column %s", spec, column)
+				}
+				if direction != "ASC" && direction != "DESC" {
+					t.Errorf("parseSort(%q) produced the direction %s", spec, direction)
+				}
+			}
+		}
+	}
+}
+
+func TestParseSortRejects(t *testing.T) {
+	for _, spec := range []string{
+		"",
+		"publication_year",
+		"title;DROP TABLE books",
+		"`title`",
+		"title DESC",
+		"--title",
+		"title,title",
+		"notes",
+	} {
+		if order, err := parseSort(spec); err == nil {
+			t.Errorf("parseSort(%q) = %s, want an error", spec, order)
+		}
+	}
+}
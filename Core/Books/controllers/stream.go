@@ -0,0 +1,56 @@
+package controllers
+
+import (
+	"database/sql"
+	"fmt"
+	"golang-api-rest-swagger/Core/Books/models"
+	"golang-api-rest-swagger/Core/Shared/config"
+	"golang-api-rest-swagger/Core/Shared/respond"
+	"net/http"
+)
+
+// shouldStream reports whether a JSON list of expected books is streamed instead of buffered.
+func shouldStream(listing config.Listing, expected int) bool {
+	return listing.StreamThreshold > 0 && expected > listing.StreamThreshold
+}
+
+// bookStream is a response written one book at a time, a respond.JSONArrayStream or a
+// respond.NDJSONStream.
+type bookStream interface {
+	Write(v any) error
+	Close()
+	Fail(err error)
+}
+
+// streamBookArray writes the books of rows, selected with the SELECT list of p and rendered with
+// render, as a JSON array, one element at a time, without holding the result set in memory.
+func streamBookArray(w http.ResponseWriter, r *http.Request, rows *sql.Rows, p projection, render func(models.Book) (any, error)) {
+	writeBookStream(respond.NewJSONArrayStream(w, r), rows, p, render)
+}
+
+// writeBookStream writes the books of rows, selected with the SELECT list of p and rendered with
+// render, to stream, and ends it.
+func writeBookStream(stream bookStream, rows *sql.Rows, p projection, render func(models.Book) (any, error)) {
+	for rows.Next() {
+		book, err := p.scan(rows)
+		if err != nil {
+			stream.Fail(fmt.Errorf("failed to scan row: %v", err))
+			return
+		}
+		body, err := render(book)
+		if err != nil {
+			stream.Fail(err)
+			return
+		}
+		if err := stream.Write(body); err != nil {
+			// Either the stream reached MAX_RESPONSE_BYTES and was ended, or the client is most likely
+			// gone and there is no one left to report the error to.
+			return
+		}
+	}
+	if err := rows.Err(); err != nil {
+		stream.Fail(fmt.Errorf("error during row iteration: %v", err))
+		return
+	}
+	stream.Close()
+}
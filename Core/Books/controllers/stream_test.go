@@ -0,0 +1,51 @@
+package controllers
+
+import (
+	"database/sql"
+	"database/sql/driver"
+	"golang-api-rest-swagger/Core/Books/models"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestBookStreams(t *testing.T) {
+	fields, err := parseProjection("title", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	columns := []string{"id", "title"}
+	books := [][]driver.Value{{int64(1), "Dune"}, {int64(2), "Emma"}}
+	// The second id does not scan into an int64, failing the stream on its row.
+	broken := [][]driver.Value{{int64(1), "Dune"}, {"x", "Emma"}}
+	type streamFunc func(http.ResponseWriter, *http.Request, *sql.Rows, projection, func(models.Book) (any, error))
+	tests := []struct {
+		name   string
+		stream streamFunc
+		rows   [][]driver.Value
+		want   string
+		// complete is false when the body only starts with want.
+		complete bool
+	}{
+		{"array", streamBookArray, books, `[{"id":1,"title":"Dune"},{"id":2,"title":"Emma"}]` + "\n", true},
+		// The array is left unterminated, telling the client the list is incomplete.
+		{"array failing", streamBookArray, broken, `[{"id":1,"title":"Dune"}`, true},
+		{"ndjson", streamBooks, books, `{"id":1,"title":"Dune"}` + "\n" + `{"id":2,"title":"Emma"}` + "\n", true},
+		{"ndjson failing", streamBooks, broken, `{"id":1,"title":"Dune"}` + "\n" + `{"error":"failed to scan row: `, false},
+	}
+	for _, tt := range tests {
+		db, _ := openFakeDB(t, fakeResult{columns: columns, rows: tt.rows})
+		rows, err := db.Query("SELECT `id`, `title` FROM `books`")
+		if err != nil {
+			t.Fatal(err)
+		}
+		rec := httptest.NewRecorder()
+		tt.stream(rec, httptest.NewRequest("GET", "/books", nil), rows, fields, fields.render)
+		rows.Close()
+		body := rec.Body.String()
+		if tt.complete && body != tt.want || !tt.complete && !strings.HasPrefix(body, tt.want) {
+			t.Errorf("%s: body = %q, want %q", tt.name, body, tt.want)
+		}
+	}
+}
@@ -0,0 +1,213 @@
+package controllers
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"golang-api-rest-swagger/Core/Books/database"
+	"golang-api-rest-swagger/Core/Books/models"
+	"golang-api-rest-swagger/Core/Shared/auth"
+	"golang-api-rest-swagger/Core/Shared/config"
+	"golang-api-rest-swagger/Core/Shared/respond"
+	"net/http"
+	"time"
+)
+
+// Outcomes of a book of UpsertBooks.
+const (
+	upsertInserted  = "inserted"
+	upsertUpdated   = "updated"
+	upsertRestored  = "restored"
+	upsertUnchanged = "unchanged"
+)
+
+// UpsertResult is the outcome of one book of an upsert, in the order of the request.
+type UpsertResult struct {
+	ISBN   string `json:"isbn" example:"9780261102217"`
+	ID     int64  `json:"id" example:"1"`
+	Result string `json:"result" enums:"inserted,updated,restored,unchanged" example:"updated"`
+}
+
+// upsertInvalid reports a book of an upsert failing validation. Whether the create defaults apply
+// depends on the book being new, which is only known once the stored books are read.
+type upsertInvalid struct {
+	index int
+	errs  models.FieldErrors
+}
+
+func (e upsertInvalid) Error() string {
+	return fmt.Sprintf("book %d: %s", e.index, e.errs.Error())
+}
+
+// upsertConflict reports a book of an upsert that would get the title and author of another book,
+// when they are unique, or an ISBN stored meanwhile.
+type upsertConflict struct {
+	index   int
+	message string
+}
+
+func (e upsertConflict) Error() string {
+	return fmt.Sprintf("book %d: %s", e.index, e.message)
+}
+
+// UpsertBooks handles keeping the catalog in sync with an external source, matching books by ISBN.
+// @Summary Create or update books by ISBN
+// @Description Create or update several books in a single transaction, matching each by its ISBN: the book
+// @Description with that ISBN is replaced, and unknown ISBNs are inserted with the defaults of POST /books.
+// @Description Every book needs an ISBN, at most once per batch; ids in the body are ignored. The result of
+// @Description each book, in the order of the request, is inserted, updated, restored when the stored book was
+// @Description soft deleted, or unchanged when it already matched. The batch is limited like POST /books/bulk.
+// @Tags books
+// @Accept json
+// @Produce json
+// @Param books body []models.BookInput true "Books to create or update"
+// @Success 200 {array} UpsertResult
+// @Failure 400 {string} string "Invalid request body"
+// @Failure 415 {string} string "Request body not sent as application/json"
+// @Failure 413 {object} BulkLimitError
+// @Failure 409 {string} string "A book with the title and author of another one, when ENFORCE_UNIQUE_TITLE_AUTHOR is enabled"
+// @Router /books/upsert [post]
+func UpsertBooks(w http.ResponseWriter, r *http.Request, db *sql.DB, bulk config.Bulk) {
+	w.Header().Set("Content-Type", "application/json")
+	books, ok := decodeBulkBooks(w, r, bulk)
+	if !ok {
+		return
+	}
+
+	seen := make(map[string]int, len(books))
+	for i := range books {
+		books[i].ID = 0
+		if books[i].ISBN == "" {
+			countValidationFailures("UpsertBooks", models.FieldErrors{{Field: "isbn"}})
+			respond.Error(w, fmt.Sprintf("Invalid request body: book %d: isbn is required to match the book", i), http.StatusBadRequest)
+			return
+		}
+		if j, dup := seen[books[i].ISBN]; dup {
+			respond.Error(w, fmt.Sprintf("Invalid request body: books %d and %d have the same isbn %s", j, i, books[i].ISBN), http.StatusBadRequest)
+			return
+		}
+		seen[books[i].ISBN] = i
+	}
+
+	results, err := upsertBooks(r, db, books, time.Now())
+	var invalid upsertInvalid
+	var conflict upsertConflict
+	switch {
+	case errors.As(err, &invalid):
+		respond.Error(w, "Invalid request body: "+invalid.Error(), http.StatusBadRequest)
+	case errors.As(err, &conflict):
+		respond.Error(w, "Conflict: "+conflict.Error(), http.StatusConflict)
+	case err != nil:
+		respond.Error(w, fmt.Sprintf("Database upsert failed: %v", err), http.StatusInternalServerError)
+	default:
+		respond.JSON(w, r, http.StatusOK, results)
+	}
+}
+
+// upsertBooks writes books with distinct ISBNs and audits the changes, all in one transaction. The
+// books stored under these ISBNs, soft deleted ones included, are locked and read first: they are
+// updated by id, keeping the fields the caller may not see, and the other books are inserted. A
+// plain UPDATE and INSERT, rather than INSERT ... ON DUPLICATE KEY UPDATE, so a book taking the
+// title and author of another one fails instead of updating that other book.
+func upsertBooks(r *http.Request, db *sql.DB, books []models.Book, now time.Time) ([]UpsertResult, error) {
+	table := database.Table(database.Books)
+	var results []UpsertResult
+	err := database.WithTx(r.Context(), db, func(tx *sql.Tx) error {
+		// Reset on every attempt, as the transaction may be retried.
+		results = make([]UpsertResult, len(books))
+
+		isbns := make([]any, len(books))
+		for i, book := range books {
+			isbns[i] = book.ISBN
+		}
+		rows, err := tx.QueryContext(r.Context(), fmt.Sprintf("SELECT `id`, `title`, `author`, `publication_year`, `cover_url`, `notes`, `isbn`, `deleted_at` IS NOT NULL FROM %s WHERE `isbn` IN (%s) FOR UPDATE", table, placeholders(len(isbns))), isbns...)
+		if err != nil {
+			return err
+		}
+		stored := make(map[string]models.Book, len(books))
+		deleted := make(map[string]bool)
+		for rows.Next() {
+			var book models.Book
+			var isDeleted bool
+			if err := rows.Scan(&book.ID, &book.Title, &book.Author, &book.Year, &book.CoverURL, &book.Notes, &book.ISBN, &isDeleted); err != nil {
+				rows.Close()
+				return err
+			}
+			stored[book.ISBN] = book
+			deleted[book.ISBN] = isDeleted
+		}
+		rows.Close()
+		if err := rows.Err(); err != nil {
+			return err
+		}
+
+		insert, err := tx.PrepareContext(r.Context(), fmt.Sprintf("INSERT INTO %s (`title`, `author`, `publication_year`, `cover_url`, `notes`, `isbn`) VALUES (?, ?, ?, ?, ?, ?)", table))
+		if err != nil {
+			return err
+		}
+		defer insert.Close()
+		// Clearing deleted_at restores a soft deleted book: the source still lists it.
+		update, err := tx.PrepareContext(r.Context(), fmt.Sprintf("UPDATE %s SET `title` = ?, `author` = ?, `publication_year` = ?, `cover_url` = ?, `notes` = ?, `deleted_at` = NULL WHERE `id` = ?", table))
+		if err != nil {
+			return err
+		}
+		defer update.Close()
+
+		for i := range books {
+			book := books[i]
+			before, exists := stored[book.ISBN]
+			keepHiddenFields(r, &book, before)
+			if !exists {
+				if errs := validateNewBook("UpsertBooks", &book, now); errs != nil {
+					return upsertInvalid{index: i, errs: errs}
+				}
+				result, err := insert.ExecContext(r.Context(), book.Title, book.Author, book.Year, book.CoverURL, book.Notes, book.ISBN)
+				if message, conflict := conflictMessage(err); conflict {
+					return upsertConflict{index: i, message: message}
+				}
+				if err != nil {
+					return err
+				}
+				if book.ID, err = result.LastInsertId(); err != nil {
+					return fmt.Errorf("failed to get last insert ID: %w", err)
+				}
+				results[i] = UpsertResult{ISBN: book.ISBN, ID: book.ID, Result: upsertInserted}
+				if err := database.RecordAudit(tx, database.EntityBook, book.ID, database.ActionCreate, auth.Actor(r.Context()), book); err != nil {
+					return err
+				}
+				continue
+			}
+
+			if errs := validateBook("UpsertBooks", book); errs != nil {
+				return upsertInvalid{index: i, errs: errs}
+			}
+			book.ID = before.ID
+			result, err := update.ExecContext(r.Context(), book.Title, book.Author, book.Year, book.CoverURL, book.Notes, book.ID)
+			if message, conflict := conflictMessage(err); conflict {
+				return upsertConflict{index: i, message: message}
+			}
+			if err != nil {
+				return err
+			}
+			// MySQL counts the rows actually changed, none when the stored book already matched.
+			affected, err := result.RowsAffected()
+			if err != nil {
+				return err
+			}
+			results[i] = UpsertResult{ISBN: book.ISBN, ID: book.ID, Result: upsertUnchanged}
+			switch {
+			case deleted[book.ISBN]:
+				results[i].Result = upsertRestored
+				err = database.RecordAudit(tx, database.EntityBook, book.ID, database.ActionRestore, auth.Actor(r.Context()), BookDiff{Before: &before, After: book})
+			case affected > 0:
+				results[i].Result = upsertUpdated
+				err = database.RecordAudit(tx, database.EntityBook, book.ID, database.ActionUpdate, auth.Actor(r.Context()), BookDiff{Before: &before, After: book})
+			}
+			if err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	return results, err
+}
@@ -0,0 +1,71 @@
+package controllers
+
+import (
+	"expvar"
+	"golang-api-rest-swagger/Core/Books/models"
+	"golang-api-rest-swagger/Core/Shared/metrics"
+	"golang-api-rest-swagger/Core/Shared/respond"
+	"net/http"
+	"time"
+)
+
+// ValidationResult is the response of ValidateBook. Errors is omitted when the book is valid.
+type ValidationResult struct {
+	Valid  bool               `json:"valid" example:"false"`
+	Errors models.FieldErrors `json:"errors,omitempty"`
+}
+
+// validationFailures counts the validation failures, published on /metrics under keys of the
+// form "<handler>.<field>", e.g. "CreateBook.title".
+var validationFailures = expvar.NewMap("validation_failures")
+
+func init() {
+	metrics.Describe("validation_failures", metrics.Description{Type: metrics.Counter, Help: "Validation failures, keyed by handler and field as CreateBook.title.", Label: "field"})
+}
+
+// validateBook checks book against the validation rules, counting the failures under handler.
+func validateBook(handler string, book models.Book) models.FieldErrors {
+	errs := models.Validate(book)
+	countValidationFailures(handler, errs)
+	return errs
+}
+
+// countValidationFailures adds one failure of each field in errs to the metrics of handler.
+func countValidationFailures(handler string, errs models.FieldErrors) {
+	for _, e := range errs {
+		validationFailures.Add(handler+"."+e.Field, 1)
+	}
+}
+
+// validateNewBook applies the create defaults to book and checks it against the validation
+// rules. It is shared by every endpoint creating books, so they all accept the same payloads.
+func validateNewBook(handler string, book *models.Book, now time.Time) models.FieldErrors {
+	book.ApplyCreateDefaults(now)
+	return validateBook(handler, *book)
+}
+
+// ValidateBook handles checking a book payload without saving it.
+// @Summary Validate a book
+// @Description Run the validation rules of POST /books against a book without saving it or touching the database
+// @Tags books
+// @Accept json
+// @Produce json
+// @Param book body models.BookInput true "Book to validate"
+// @Success 200 {object} ValidationResult
+// @Failure 400 {string} string "Invalid request body"
+// @Failure 415 {string} string "Request body not sent as application/json"
+// @Failure 422 {object} ValidationResult
+// @Router /books/validate [post]
+func ValidateBook(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	var book models.Book
+	if err := decodeJSON(r, &book); err != nil {
+		respond.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	if errs := validateNewBook("ValidateBook", &book, time.Now()); errs != nil {
+		respond.Fail(w, r, http.StatusUnprocessableEntity, ValidationResult{Valid: false, Errors: errs})
+		return
+	}
+	respond.JSON(w, r, http.StatusOK, ValidationResult{Valid: true})
+}
@@ -0,0 +1,56 @@
+package controllers
+
+import (
+	"encoding/json"
+	"net/http"
+	"testing"
+)
+
+func TestValidateBook(t *testing.T) {
+	tests := []struct {
+		name       string
+		body       string
+		wantStatus int
+		// wantFields are the fields reported invalid.
+		wantFields []string
+	}{
+		{"valid", `{"title":"Dune","author":"Frank Herbert","year":1965}`, http.StatusOK, nil},
+		{"missing year defaulted", `{"title":"Dune","author":"Frank Herbert"}`, http.StatusOK, nil},
+		{"missing title", `{"author":"Frank Herbert","year":1965}`, http.StatusUnprocessableEntity, []string{"title"}},
+		{"missing title and author", `{"year":1965}`, http.StatusUnprocessableEntity, []string{"title", "author"}},
+		{"invalid cover", `{"title":"Dune","author":"Frank Herbert","year":1965,"cover_url":"not a url"}`, http.StatusUnprocessableEntity, []string{"cover_url"}},
+		{"malformed", `{"title":`, http.StatusBadRequest, nil},
+	}
+	for _, tt := range tests {
+		rec := serve(ValidateBook, "POST", "/books/validate", nil, tt.body)
+		if rec.Code != tt.wantStatus {
+			t.Errorf("%s: status = %d, want %d: %s", tt.name, rec.Code, tt.wantStatus, rec.Body)
+			continue
+		}
+		if tt.wantStatus == http.StatusBadRequest {
+			continue
+		}
+		var result ValidationResult
+		if err := json.NewDecoder(rec.Body).Decode(&result); err != nil {
+			t.Errorf("%s: decode: %v", tt.name, err)
+			continue
+		}
+		if result.Valid != (tt.wantStatus == http.StatusOK) {
+			t.Errorf("%s: valid = %t, want %t", tt.name, result.Valid, tt.wantStatus == http.StatusOK)
+		}
+		var fields []string
+		for _, e := range result.Errors {
+			fields = append(fields, e.Field)
+		}
+		if len(fields) != len(tt.wantFields) {
+			t.Errorf("%s: invalid fields = %v, want %v", tt.name, fields, tt.wantFields)
+			continue
+		}
+		for i := range fields {
+			if fields[i] != tt.wantFields[i] {
+				t.Errorf("%s: invalid fields = %v, want %v", tt.name, fields, tt.wantFields)
+				break
+			}
+		}
+	}
+}
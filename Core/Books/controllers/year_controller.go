@@ -0,0 +1,56 @@
+package controllers
+
+import (
+	"database/sql"
+	"fmt"
+	"golang-api-rest-swagger/Core/Books/database"
+	"golang-api-rest-swagger/Core/Books/models"
+	"golang-api-rest-swagger/Core/Shared/debugsql"
+	"golang-api-rest-swagger/Core/Shared/respond"
+	"net/http"
+)
+
+// GetBookYears handles counting the books published in each year.
+// @Summary Count books per year
+// @Description List the years books were published in with the number of books of each, ordered by year,
+// @Description for building a year filter. With author, only the books of that author are counted.
+// @Tags books
+// @Produce json
+// @Param author query string false "Only count the books of this author" example(Tolkien)
+// @Success 200 {array} models.YearCount
+// @Router /books/years [get]
+func GetBookYears(w http.ResponseWriter, r *http.Request, db *sql.DB) {
+	w.Header().Set("Content-Type", "application/json")
+
+	where := "WHERE `deleted_at` IS NULL AND `archived_at` IS NULL"
+	var args []any
+	if author := r.URL.Query().Get("author"); author != "" {
+		where += " AND `author` = ?"
+		args = append(args, author)
+	}
+
+	query := fmt.Sprintf("SELECT `publication_year`, COUNT(*) FROM %s %s GROUP BY `publication_year` ORDER BY `publication_year`", database.Table(database.Books), where)
+	debugsql.Record(r.Context(), query, args...)
+	rows, err := db.QueryContext(r.Context(), query, args...)
+	if err != nil {
+		respond.Error(w, fmt.Sprintf("Database query failed: %v", err), http.StatusInternalServerError)
+		return
+	}
+	defer rows.Close()
+
+	years := []models.YearCount{}
+	for rows.Next() {
+		var year models.YearCount
+		if err := rows.Scan(&year.Year, &year.Count); err != nil {
+			respond.Error(w, fmt.Sprintf("Failed to scan row: %v", err), http.StatusInternalServerError)
+			return
+		}
+		years = append(years, year)
+	}
+	if err := rows.Err(); err != nil {
+		respond.Error(w, fmt.Sprintf("Error during row iteration: %v", err), http.StatusInternalServerError)
+		return
+	}
+
+	respond.JSON(w, r, http.StatusOK, years)
+}
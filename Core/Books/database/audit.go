@@ -0,0 +1,38 @@
+package database
+
+import (
+	"database/sql"
+	"encoding/json"
+	"fmt"
+)
+
+// Audited entities and actions.
+const (
+	EntityBook = "book"
+
+	ActionCreate  = "create"
+	ActionUpdate  = "update"
+	ActionDelete  = "delete"
+	ActionArchive = "archive"
+	ActionRestore = "restore"
+)
+
+// RecordAudit appends an entry to the audit log. It takes the transaction of the mutation being
+// audited, so the entry is only kept when the mutation commits. payload may be nil.
+func RecordAudit(tx *sql.Tx, entity string, entityID int64, action, actor string, payload any) error {
+	var data []byte
+	if payload != nil {
+		var err error
+		if data, err = json.Marshal(payload); err != nil {
+			return fmt.Errorf("failed to encode audit payload: %w", err)
+		}
+	}
+	_, err := tx.Exec(
+		fmt.Sprintf("INSERT INTO %s (`entity`, `entity_id`, `action`, `actor`, `payload`) VALUES (?, ?, ?, ?, ?)", Table(AuditLog)),
+		entity, entityID, action, actor, data,
+	)
+	if err != nil {
+		return fmt.Errorf("failed to record audit entry: %w", err)
+	}
+	return nil
+}
@@ -1,70 +1,155 @@
 package database
 
 import (
+	"context"
 	"database/sql"
 	"fmt"
 	_ "github.com/go-sql-driver/mysql"
-	"github.com/joho/godotenv"
-	"log"
-	"os"
+	"golang-api-rest-swagger/Core/Shared/config"
+	"golang-api-rest-swagger/Core/Shared/consistency"
+	"log/slog"
+	"strings"
+	"time"
 )
 
 // DB is the database connection
 var DB *sql.DB
 
+// Base names of the tables managed by this package. Queries must wrap them in Table so
+// the configured TABLE_PREFIX is applied.
+const (
+	Books     = "books"
+	Favorites = "favorites"
+	AuditLog  = "audit_log"
+)
+
+// tablePrefix is prepended to every table name. It is set by InitDB.
+var tablePrefix string
+
+// Table returns the name of the given table with the configured prefix applied, quoted.
+func Table(name string) string {
+	return QuoteIdent(tablePrefix + name)
+}
+
+// QuoteIdent quotes name as a MySQL identifier, so reserved words like YEAR and mixed-case names
+// are always read as identifiers. Queries quote every table and column name this way.
+func QuoteIdent(name string) string {
+	return "`" + strings.ReplaceAll(name, "`", "``") + "`"
+}
+
+// Pools are the connections to the primary, which takes every write, and to the read replica.
+// Replica is the primary itself when no replica is configured.
+type Pools struct {
+	Primary *sql.DB
+	Replica *sql.DB
+	// ReadYourWrites is how long the reads of a client that wrote are served by the primary, zero
+	// without a replica.
+	ReadYourWrites time.Duration
+}
+
+// Reader returns the connection serving the reads of the request ctx belongs to: the replica,
+// unless the client wrote within ReadYourWrites.
+func (p Pools) Reader(ctx context.Context) *sql.DB {
+	if consistency.UsePrimary(ctx) {
+		return p.Primary
+	}
+	return p.Replica
+}
+
 // InitDB initializes the database connection.
-func InitDB() (*sql.DB, error) {
-	// Load environment variables from .env file
-	err := godotenv.Load()
+func InitDB(cfg config.Database) (*sql.DB, error) {
+	tablePrefix = cfg.TablePrefix
+
+	var err error
+	DB, err = open(cfg, cfg.Host, cfg.Port, cfg.User, cfg.Password)
 	if err != nil {
-		log.Println("Error loading .env file, using system env variables") // use log.Println instead of log.Fatalf
+		return nil, err
 	}
 
-	// Retrieve database credentials from environment variables
-	dbUser := os.Getenv("MYSQL_USER")
-	dbPass := os.Getenv("MYSQL_PASSWORD")
-	dbName := os.Getenv("MYSQL_DATABASE")
-	dbHost := os.Getenv("MYSQL_HOST")
-	dbPort := os.Getenv("MYSQL_PORT")
+	// Leave the schema to the separate migration step when there is one, refusing to serve an
+	// outdated schema.
+	if !cfg.RunMigrations {
+		version, err := checkSchema(DB)
+		if err != nil {
+			return nil, err
+		}
+		slog.Info("migrations.skipped", "version", version)
+		if err := checkUniqueTitleAuthor(DB, cfg.UniqueTitleAuthor); err != nil {
+			return nil, err
+		}
+		return DB, nil
+	}
 
-	// Check if the environment variables are set.
-	if dbUser == "" || dbPass == "" || dbName == "" || dbHost == "" || dbPort == "" {
-		return nil, fmt.Errorf("database credentials not set in .env or system environment")
+	// Bring the schema up to date.
+	start := time.Now()
+	version, applied, err := migrate(DB)
+	if err != nil {
+		return nil, err
+	}
+	slog.Info("migrations.applied", "version", version, "applied", applied, "duration", time.Since(start))
+
+	if err := syncUniqueTitleAuthor(DB, cfg.UniqueTitleAuthor); err != nil {
+		return nil, err
 	}
 
+	return DB, nil
+}
+
+// InitPools initializes the connection to the primary, as InitDB does, and the one to the read
+// replica when MYSQL_READ_HOST is set. The replica gets the schema through replication, so
+// migrations only ever run on the primary.
+func InitPools(cfg config.Database) (Pools, error) {
+	primary, err := InitDB(cfg)
+	if err != nil {
+		return Pools{}, err
+	}
+	if cfg.ReadHost == "" {
+		return Pools{Primary: primary, Replica: primary}, nil
+	}
+	replica, err := open(cfg, cfg.ReadHost, cfg.ReadPort, cfg.ReadUser, cfg.ReadPassword)
+	if err != nil {
+		primary.Close()
+		return Pools{}, fmt.Errorf("read replica: %v", err)
+	}
+	return Pools{Primary: primary, Replica: replica, ReadYourWrites: cfg.ReadYourWrites}, nil
+}
+
+// Close closes the connections, the replica's only when it is not the primary.
+func (p Pools) Close() error {
+	if p.Replica != p.Primary {
+		p.Replica.Close()
+	}
+	return p.Primary.Close()
+}
+
+// open connects to the server at host:port with the database name, parameters and pool settings
+// of cfg.
+func open(cfg config.Database, host, port, user, password string) (*sql.DB, error) {
 	// Construct the connection string
-	dsn := fmt.Sprintf("%s:%s@tcp(%s:%s)/%s", dbUser, dbPass, dbHost, dbPort, dbName)
+	dsn := fmt.Sprintf("%s:%s@tcp(%s:%s)/%s", user, password, host, port, cfg.Name)
+	if cfg.Params != "" {
+		dsn += "?" + cfg.Params
+	}
 
 	// Connect to the database
-	DB, err = sql.Open("mysql", dsn)
+	slog.Info("db.connecting", "host", host, "port", port, "name", cfg.Name)
+	start := time.Now()
+	db, err := sql.Open("mysql", dsn)
 	if err != nil {
 		return nil, fmt.Errorf("failed to connect to database: %v", err)
 	}
 
 	// Set maximum number of connections
-	DB.SetMaxOpenConns(10)
-	DB.SetMaxIdleConns(5)
-	DB.SetConnMaxLifetime(0)
+	db.SetMaxOpenConns(cfg.MaxOpenConns)
+	db.SetMaxIdleConns(cfg.MaxIdleConns)
+	db.SetConnMaxLifetime(cfg.ConnMaxLifetime)
 
 	// Check if the connection is working
-	if err = DB.Ping(); err != nil {
+	if err = db.Ping(); err != nil {
+		db.Close()
 		return nil, fmt.Errorf("failed to ping database: %v", err)
 	}
 
-	log.Println("Successfully connected to MySQL database!")
-
-	// Create the books table if it doesn't exist.
-	_, err = DB.Exec(`
-		CREATE TABLE IF NOT EXISTS books (
-			id INT AUTO_INCREMENT PRIMARY KEY,
-			title VARCHAR(255) NOT NULL,
-			author VARCHAR(255) NOT NULL,
-			YEAR INT NOT NULL
-		)
-	`)
-	if err != nil {
-		return nil, fmt.Errorf("failed to create table: %v", err)
-	}
-
-	return DB, nil
+	slog.Info("db.connected", "host", host, "duration", time.Since(start))
+	return db, nil
 }
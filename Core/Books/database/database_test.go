@@ -0,0 +1,85 @@
+package database
+
+import (
+	"errors"
+	"fmt"
+	"github.com/go-sql-driver/mysql"
+	"slices"
+	"testing"
+)
+
+func TestQuoteIdent(t *testing.T) {
+	tests := []struct {
+		name string
+		want string
+	}{
+		{"books", "`books`"},
+		{"year", "`year`"},
+		{"Mixed_Case", "`Mixed_Case`"},
+		{"a`b", "`a``b`"},
+		{"``", "``````"},
+		{"", "``"},
+	}
+	for _, tt := range tests {
+		if got := QuoteIdent(tt.name); got != tt.want {
+			t.Errorf("QuoteIdent(%q) = %s, want %s", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestTable(t *testing.T) {
+	tests := []struct {
+		prefix string
+		name   string
+		want   string
+	}{
+		{"", Books, "`books`"},
+		{"app_", Books, "`app_books`"},
+		{"app_", AuditLog, "`app_audit_log`"},
+		{"a`b_", Favorites, "`a``b_favorites`"},
+	}
+	defer func(prefix string) { tablePrefix = prefix }(tablePrefix)
+	for _, tt := range tests {
+		tablePrefix = tt.prefix
+		if got := Table(tt.name); got != tt.want {
+			t.Errorf("Table(%q) with prefix %q = %s, want %s", tt.name, tt.prefix, got, tt.want)
+		}
+	}
+}
+
+func TestAlreadyApplied(t *testing.T) {
+	tests := []struct {
+		name     string
+		err      error
+		tolerate []uint16
+		want     bool
+	}{
+		{"existing table", &mysql.MySQLError{Number: erTableExists}, nil, true},
+		{"existing column", &mysql.MySQLError{Number: erDupFieldName}, nil, true},
+		{"existing index", &mysql.MySQLError{Number: erDupKeyName}, nil, true},
+		{"unknown column", &mysql.MySQLError{Number: erBadFieldName}, nil, false},
+		{"unknown column tolerated", &mysql.MySQLError{Number: erBadFieldName}, []uint16{erBadFieldName}, true},
+		{"missing key", &mysql.MySQLError{Number: erCantDropFieldOrKey}, nil, false},
+		{"existing foreign key", &mysql.MySQLError{Number: erFKDupName}, nil, false},
+		{"other error tolerated elsewhere", &mysql.MySQLError{Number: erNoSuchTable}, []uint16{erBadFieldName}, false},
+		{"wrapped", fmt.Errorf("exec: %w", &mysql.MySQLError{Number: erDupKeyName}), nil, true},
+		{"not a MySQL error", errors.New("connection refused"), []uint16{erBadFieldName}, false},
+	}
+	for _, tt := range tests {
+		if got := alreadyApplied(tt.err, tt.tolerate...); got != tt.want {
+			t.Errorf("%s: alreadyApplied(%v, %v) = %t, want %t", tt.name, tt.err, tt.tolerate, got, tt.want)
+		}
+	}
+}
+
+func TestToleratedErrorsStayWithTheirMigration(t *testing.T) {
+	// The migration each error is tolerated by, the only one expected to list it.
+	owners := map[uint16]int{erBadFieldName: 7, erCantDropFieldOrKey: 14, erFKDupName: 14}
+	for _, m := range migrations {
+		for number, version := range owners {
+			if slices.Contains(m.tolerate, number) != (m.version == version) {
+				t.Errorf("migration %d (%s): tolerating error %d is %t, want %t", m.version, m.description, number, slices.Contains(m.tolerate, number), m.version == version)
+			}
+		}
+	}
+}
@@ -0,0 +1,60 @@
+//go:build integration
+
+// Package dbtest connects the integration tests to the MySQL server of TEST_MYSQL_DSN.
+package dbtest
+
+import (
+	"fmt"
+	"github.com/go-sql-driver/mysql"
+	"golang-api-rest-swagger/Core/Books/database"
+	"golang-api-rest-swagger/Core/Shared/config"
+	"net"
+	"os"
+	"testing"
+	"time"
+)
+
+// Open loads the configuration pointing at the server of TEST_MYSQL_DSN and runs the migrations
+// on tables with a prefix of their own, dropped once the test is over. The test is skipped when
+// TEST_MYSQL_DSN is not set.
+func Open(t testing.TB) (config.Config, database.Pools) {
+	t.Helper()
+	dsn := os.Getenv("TEST_MYSQL_DSN")
+	if dsn == "" {
+		t.Skip("TEST_MYSQL_DSN is not set, e.g. root:secret@tcp(127.0.0.1:3306)/books_test")
+	}
+	parsed, err := mysql.ParseDSN(dsn)
+	if err != nil {
+		t.Fatalf("invalid TEST_MYSQL_DSN: %v", err)
+	}
+	host, port, err := net.SplitHostPort(parsed.Addr)
+	if err != nil {
+		t.Fatalf("invalid TEST_MYSQL_DSN address %q: %v", parsed.Addr, err)
+	}
+	t.Setenv("MYSQL_USER", parsed.User)
+	t.Setenv("MYSQL_PASSWORD", parsed.Passwd)
+	t.Setenv("MYSQL_DATABASE", parsed.DBName)
+	t.Setenv("MYSQL_HOST", host)
+	t.Setenv("MYSQL_PORT", port)
+	t.Setenv("MYSQL_READ_HOST", "")
+	t.Setenv("TABLE_PREFIX", fmt.Sprintf("it%d_", time.Now().UnixNano()))
+	t.Setenv("RUN_MIGRATIONS", "true")
+
+	cfg, err := config.Load()
+	if err != nil {
+		t.Fatalf("config.Load: %v", err)
+	}
+	pools, err := database.InitPools(cfg.Database)
+	if err != nil {
+		t.Fatalf("InitPools: %v", err)
+	}
+	t.Cleanup(func() {
+		for _, table := range []string{database.Favorites, database.AuditLog, database.Books, database.SchemaMigrations} {
+			if _, err := pools.Primary.Exec("DROP TABLE IF EXISTS " + database.Table(table)); err != nil {
+				t.Errorf("drop %s: %v", table, err)
+			}
+		}
+		pools.Close()
+	})
+	return cfg, pools
+}
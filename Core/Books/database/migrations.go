@@ -0,0 +1,337 @@
+package database
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"github.com/go-sql-driver/mysql"
+	"log"
+	"slices"
+)
+
+// SchemaMigrations is the base name of the table recording the applied migrations.
+const SchemaMigrations = "schema_migrations"
+
+// migration is a schema change applied once, in version order, and recorded in schema_migrations.
+type migration struct {
+	version     int
+	description string
+	// statements builds the SQL to run. It is a function so table names pick up the prefix set by
+	// InitDB, and so a change can look at the schema first to leave out what is already done.
+	statements func(db *sql.DB) ([]string, error)
+	// tolerate lists the MySQL errors that, for this migration only, mean a statement is already
+	// in place, on top of the ones alreadyApplied always accepts.
+	tolerate []uint16
+}
+
+// migrations lists every schema change. Append new migrations at the end, quoting their column
+// names; never edit one that has been released. The first ones use IF NOT EXISTS because they
+// were created by InitDB before migrations were tracked.
+var migrations = []migration{
+	{
+		version:     1,
+		description: "create books table",
+		statements: func(db *sql.DB) ([]string, error) {
+			return []string{fmt.Sprintf(`
+				CREATE TABLE IF NOT EXISTS %s (
+					id INT AUTO_INCREMENT PRIMARY KEY,
+					title VARCHAR(255) NOT NULL,
+					author VARCHAR(255) NOT NULL,
+					YEAR INT NOT NULL
+				)`, Table(Books))}, nil
+		},
+	},
+	{
+		version:     2,
+		description: "create favorites table",
+		statements: func(db *sql.DB) ([]string, error) {
+			return []string{fmt.Sprintf(`
+				CREATE TABLE IF NOT EXISTS %s (
+					subject VARCHAR(255) NOT NULL,
+					book_id INT NOT NULL,
+					created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+					PRIMARY KEY (subject, book_id),
+					FOREIGN KEY (book_id) REFERENCES %s (id) ON DELETE CASCADE
+				)`, Table(Favorites), Table(Books))}, nil
+		},
+	},
+	{
+		version:     3,
+		description: "add books.deleted_at for soft deletes",
+		statements: func(db *sql.DB) ([]string, error) {
+			return []string{fmt.Sprintf(`ALTER TABLE %s ADD COLUMN deleted_at TIMESTAMP NULL DEFAULT NULL`, Table(Books))}, nil
+		},
+	},
+	{
+		version:     4,
+		description: "create audit_log table",
+		statements: func(db *sql.DB) ([]string, error) {
+			return []string{fmt.Sprintf(`
+				CREATE TABLE IF NOT EXISTS %s (
+					id BIGINT AUTO_INCREMENT PRIMARY KEY,
+					entity VARCHAR(64) NOT NULL,
+					entity_id INT NOT NULL,
+					action VARCHAR(32) NOT NULL,
+					actor VARCHAR(255) NOT NULL,
+					payload JSON NULL,
+					created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+					INDEX idx_audit_log_entity (entity, entity_id)
+				)`, Table(AuditLog))}, nil
+		},
+	},
+	{
+		version:     5,
+		description: "convert books to utf8mb4",
+		statements: func(db *sql.DB) ([]string, error) {
+			// CONVERT rewrites the table default and every text column, so titles with emoji and
+			// other 4-byte characters are stored as sent.
+			return []string{fmt.Sprintf(`ALTER TABLE %s CONVERT TO CHARACTER SET utf8mb4 COLLATE utf8mb4_unicode_ci`, Table(Books))}, nil
+		},
+	},
+	{
+		version:     6,
+		description: "add books.cover_url",
+		statements: func(db *sql.DB) ([]string, error) {
+			return []string{fmt.Sprintf(`ALTER TABLE %s ADD COLUMN cover_url VARCHAR(500) NOT NULL DEFAULT ''`, Table(Books))}, nil
+		},
+	},
+	{
+		version:     7,
+		description: "rename books.YEAR to publication_year",
+		statements: func(db *sql.DB) ([]string, error) {
+			// YEAR is a MySQL keyword; the new name needs no quoting. CHANGE rather than RENAME
+			// COLUMN keeps MySQL 5.7 supported.
+			renamed, err := hasColumn(db, Books, "publication_year")
+			if err != nil || renamed {
+				return nil, err
+			}
+			return []string{fmt.Sprintf("ALTER TABLE %s CHANGE COLUMN `YEAR` publication_year INT NOT NULL", Table(Books))}, nil
+		},
+		// Another instance may still rename the column between the check and the change.
+		tolerate: []uint16{erBadFieldName},
+	},
+	{
+		version:     8,
+		description: "add books.created_at",
+		statements: func(db *sql.DB) ([]string, error) {
+			// Existing books get the time of the migration, their actual creation time is unknown.
+			return []string{
+				fmt.Sprintf("ALTER TABLE %s ADD COLUMN `created_at` TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP", Table(Books)),
+				fmt.Sprintf("CREATE INDEX `idx_books_created_at` ON %s (`created_at`)", Table(Books)),
+			}, nil
+		},
+	},
+	{
+		version:     9,
+		description: "add books.updated_at",
+		statements: func(db *sql.DB) ([]string, error) {
+			// Microseconds, so two changes within the same second still give the list a new ETag.
+			return []string{fmt.Sprintf("ALTER TABLE %s ADD COLUMN `updated_at` TIMESTAMP(6) NOT NULL DEFAULT CURRENT_TIMESTAMP(6) ON UPDATE CURRENT_TIMESTAMP(6)", Table(Books))}, nil
+		},
+	},
+	{
+		version:     10,
+		description: "add books.notes",
+		statements: func(db *sql.DB) ([]string, error) {
+			return []string{fmt.Sprintf("ALTER TABLE %s ADD COLUMN `notes` VARCHAR(1000) NOT NULL DEFAULT ''", Table(Books))}, nil
+		},
+	},
+	{
+		version:     11,
+		description: "add books.isbn",
+		statements: func(db *sql.DB) ([]string, error) {
+			// NULL for the books without an ISBN, so the unique key only applies to the set ones.
+			return []string{
+				fmt.Sprintf("ALTER TABLE %s ADD COLUMN `isbn` VARCHAR(13) NULL", Table(Books)),
+				fmt.Sprintf("CREATE UNIQUE INDEX `uq_books_isbn` ON %s (`isbn`)", Table(Books)),
+			}, nil
+		},
+	},
+	{
+		version:     12,
+		description: "index audit_log by actor",
+		statements: func(db *sql.DB) ([]string, error) {
+			return []string{fmt.Sprintf("CREATE INDEX `idx_audit_log_actor` ON %s (`entity`, `actor`)", Table(AuditLog))}, nil
+		},
+	},
+	{
+		version:     13,
+		description: "add books.archived_at",
+		statements: func(db *sql.DB) ([]string, error) {
+			// NULL for the active books, which is every existing one.
+			return []string{fmt.Sprintf("ALTER TABLE %s ADD COLUMN `archived_at` TIMESTAMP NULL", Table(Books))}, nil
+		},
+	},
+	{
+		version:     14,
+		description: "widen book ids to BIGINT",
+		statements: func(db *sql.DB) ([]string, error) {
+			// The ids are int64 in the API, so every id it accepts fits. MySQL refuses to change the
+			// type of a column in a foreign key, so the one of favorites is dropped, under whatever
+			// name the schema gave it, and added back with a name of its own once both sides match.
+			keys, err := foreignKeys(db, Favorites, "book_id")
+			if err != nil {
+				return nil, err
+			}
+			var statements []string
+			for _, key := range keys {
+				statements = append(statements, fmt.Sprintf("ALTER TABLE %s DROP FOREIGN KEY %s", Table(Favorites), QuoteIdent(key)))
+			}
+			return append(statements,
+				fmt.Sprintf("ALTER TABLE %s MODIFY `id` BIGINT NOT NULL AUTO_INCREMENT", Table(Books)),
+				fmt.Sprintf("ALTER TABLE %s MODIFY `book_id` BIGINT NOT NULL", Table(Favorites)),
+				fmt.Sprintf("ALTER TABLE %s ADD CONSTRAINT %s FOREIGN KEY (`book_id`) REFERENCES %s (`id`) ON DELETE CASCADE", Table(Favorites), QuoteIdent(tablePrefix+"fk_favorites_book"), Table(Books)),
+				fmt.Sprintf("ALTER TABLE %s MODIFY `entity_id` BIGINT NOT NULL", Table(AuditLog)),
+			), nil
+		},
+		// Another instance may still drop the foreign key between the look up and the drop, or
+		// add it back before this one does.
+		tolerate: []uint16{erCantDropFieldOrKey, erFKDupName},
+	},
+	{
+		version:     15,
+		description: "index books by deletion and archiving",
+		statements: func(db *sql.DB) ([]string, error) {
+			// Lists always filter on both columns. Ending with the id, the index holds the ids of the
+			// listed books in order, so a deep page finds its first book without reading any row.
+			return []string{fmt.Sprintf("CREATE INDEX `idx_books_scope` ON %s (`deleted_at`, `archived_at`, `id`)", Table(Books))}, nil
+		},
+	},
+	{
+		version:     16,
+		description: "index books by title",
+		statements: func(db *sql.DB) ([]string, error) {
+			// For the title prefixes of starts_with, the letters of the A-Z index.
+			return []string{fmt.Sprintf("CREATE INDEX `idx_books_title` ON %s (`title`)", Table(Books))}, nil
+		},
+	},
+}
+
+// MySQL errors meaning a schema change is already in place, typically because another instance
+// booting at the same time applied the same migration first.
+const (
+	erTableExists  = 1050
+	erBadFieldName = 1054
+	erDupFieldName = 1060
+	erDupKeyName   = 1061
+	erFKDupName    = 1826
+)
+
+// alreadyApplied reports whether err says the schema change it came from is already in place,
+// either as an object that already exists or as one of the errors of tolerate.
+func alreadyApplied(err error, tolerate ...uint16) bool {
+	var mysqlErr *mysql.MySQLError
+	if !errors.As(err, &mysqlErr) {
+		return false
+	}
+	switch mysqlErr.Number {
+	case erTableExists, erDupFieldName, erDupKeyName:
+		return true
+	}
+	return slices.Contains(tolerate, mysqlErr.Number)
+}
+
+// foreignKeys returns the names of the foreign keys on the column of the table of the given base name.
+func foreignKeys(db *sql.DB, table, column string) ([]string, error) {
+	rows, err := db.Query("SELECT `CONSTRAINT_NAME` FROM information_schema.KEY_COLUMN_USAGE WHERE `TABLE_SCHEMA` = DATABASE() AND `TABLE_NAME` = ? AND `COLUMN_NAME` = ? AND `REFERENCED_TABLE_NAME` IS NOT NULL",
+		tablePrefix+table, column)
+	if err != nil {
+		return nil, fmt.Errorf("failed to look up the foreign keys of %s.%s: %v", tablePrefix+table, column, err)
+	}
+	defer rows.Close()
+	var keys []string
+	for rows.Next() {
+		var key string
+		if err := rows.Scan(&key); err != nil {
+			return nil, fmt.Errorf("failed to look up the foreign keys of %s.%s: %v", tablePrefix+table, column, err)
+		}
+		keys = append(keys, key)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to look up the foreign keys of %s.%s: %v", tablePrefix+table, column, err)
+	}
+	return keys, nil
+}
+
+// hasColumn reports whether the table of the given base name has the column.
+func hasColumn(db *sql.DB, table, column string) (bool, error) {
+	var exists bool
+	err := db.QueryRow("SELECT COUNT(*) > 0 FROM information_schema.COLUMNS WHERE `TABLE_SCHEMA` = DATABASE() AND `TABLE_NAME` = ? AND `COLUMN_NAME` = ?",
+		tablePrefix+table, column).Scan(&exists)
+	if err != nil {
+		return false, fmt.Errorf("failed to look up column %s.%s: %v", tablePrefix+table, column, err)
+	}
+	return exists, nil
+}
+
+// erNoSuchTable is the MySQL error number returned when a queried table does not exist.
+const erNoSuchTable = 1146
+
+// checkSchema returns the schema version, failing when it is behind the last migration, for
+// startups leaving the migrations to another step.
+func checkSchema(db *sql.DB) (int, error) {
+	latest := migrations[len(migrations)-1].version
+	var current int
+	err := db.QueryRow(fmt.Sprintf("SELECT COALESCE(MAX(`version`), 0) FROM %s", Table(SchemaMigrations))).Scan(&current)
+	var mysqlErr *mysql.MySQLError
+	switch {
+	case errors.As(err, &mysqlErr) && mysqlErr.Number == erNoSuchTable:
+		return 0, fmt.Errorf("schema is not initialized, version %d is required: run the migrations, or set RUN_MIGRATIONS=true", latest)
+	case err != nil:
+		return 0, fmt.Errorf("failed to read schema version: %v", err)
+	case current < latest:
+		return current, fmt.Errorf("schema version %d is behind version %d: run the migrations, or set RUN_MIGRATIONS=true", current, latest)
+	}
+	return current, nil
+}
+
+// migrate applies the migrations that have not been applied yet and returns the resulting schema
+// version with the number of migrations it applied. Several instances may run it
+// concurrently against the same database: a change another instance already made is treated as
+// applied, so every instance ends up on the same version without failing to boot.
+func migrate(db *sql.DB) (version, applied int, err error) {
+	_, err = db.Exec(fmt.Sprintf(`
+		CREATE TABLE IF NOT EXISTS %s (
+			version INT PRIMARY KEY,
+			description VARCHAR(255) NOT NULL,
+			applied_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP
+		)
+	`, Table(SchemaMigrations)))
+	if err != nil {
+		return 0, 0, fmt.Errorf("failed to create migrations table: %v", err)
+	}
+
+	var current int
+	if err := db.QueryRow(fmt.Sprintf("SELECT COALESCE(MAX(`version`), 0) FROM %s", Table(SchemaMigrations))).Scan(&current); err != nil {
+		return 0, 0, fmt.Errorf("failed to read schema version: %v", err)
+	}
+
+	for _, m := range migrations {
+		if m.version <= current {
+			continue
+		}
+		statements, err := m.statements(db)
+		if err != nil {
+			return current, applied, fmt.Errorf("migration %d (%s) failed: %v", m.version, m.description, err)
+		}
+		for _, statement := range statements {
+			if _, err := db.Exec(statement); err != nil {
+				if alreadyApplied(err, m.tolerate...) {
+					log.Printf("Migration %d (%s) already applied by another instance: %v", m.version, m.description, err)
+					continue
+				}
+				return current, applied, fmt.Errorf("migration %d (%s) failed: %v", m.version, m.description, err)
+			}
+		}
+		// IGNORE: another instance may have recorded the version in the meantime.
+		_, err = db.Exec(fmt.Sprintf("INSERT IGNORE INTO %s (`version`, `description`) VALUES (?, ?)", Table(SchemaMigrations)), m.version, m.description)
+		if err != nil {
+			return current, applied, fmt.Errorf("failed to record migration %d: %v", m.version, err)
+		}
+		log.Printf("Applied migration %d: %s", m.version, m.description)
+		current = m.version
+		applied++
+	}
+
+	return current, applied, nil
+}
@@ -0,0 +1,74 @@
+package database
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"golang-api-rest-swagger/Core/Shared/middleware"
+	"log"
+	"time"
+)
+
+// purgeBatchSize is the number of books deleted per statement, so a large purge never holds
+// locks on the books table for long.
+const purgeBatchSize = 1000
+
+// Purger permanently deletes the books soft deleted longer ago than the retention period,
+// so they do not accumulate forever. Their favorites go with them through the foreign key.
+type Purger struct {
+	db        *sql.DB
+	interval  time.Duration
+	retention time.Duration
+}
+
+// NewPurger returns a purger running every interval and deleting the books soft deleted more
+// than retention ago.
+func NewPurger(db *sql.DB, interval, retention time.Duration) *Purger {
+	return &Purger{db: db, interval: interval, retention: retention}
+}
+
+// Run purges every interval, starting one interval after it is called, until ctx is cancelled.
+// Runs are skipped in read-only mode.
+func (p *Purger) Run(ctx context.Context) {
+	ticker := time.NewTicker(p.interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+		if middleware.IsReadOnly() {
+			log.Println("Skipping the purge of deleted books in read-only mode")
+			continue
+		}
+		start := time.Now()
+		purged, err := p.purge(ctx)
+		if err != nil && ctx.Err() == nil {
+			log.Printf("Purge of deleted books failed after %d books: %v", purged, err)
+			continue
+		}
+		log.Printf("Purged %d books deleted more than %s ago in %s", purged, p.retention, time.Since(start))
+	}
+}
+
+// purge deletes the expired books in batches and returns how many it deleted.
+func (p *Purger) purge(ctx context.Context) (int64, error) {
+	// The cutoff is computed by MySQL, like deleted_at itself, so clock skew cannot shorten it.
+	query := fmt.Sprintf("DELETE FROM %s WHERE `deleted_at` IS NOT NULL AND `deleted_at` < NOW() - INTERVAL ? SECOND LIMIT %d", Table(Books), purgeBatchSize)
+	var purged int64
+	for {
+		result, err := p.db.ExecContext(ctx, query, int64(p.retention.Seconds()))
+		if err != nil {
+			return purged, err
+		}
+		n, err := result.RowsAffected()
+		if err != nil {
+			return purged, err
+		}
+		purged += n
+		if n < purgeBatchSize {
+			return purged, nil
+		}
+	}
+}
@@ -0,0 +1,53 @@
+package database
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"golang-api-rest-swagger/Core/Books/models"
+	"strings"
+)
+
+// seedBooks is the fixture catalog inserted by Seed. It is only filled in by binaries built
+// with the seed tag (go build -tags seed), so production binaries do not carry it.
+var seedBooks []models.Book
+
+// seedBatchSize is the number of books inserted per statement.
+const seedBatchSize = 100
+
+// Seed inserts the fixture catalog when the books table is empty, in a single transaction, and
+// returns the number of books inserted. It does nothing in binaries built without the seed tag,
+// or once the table holds any book, deleted ones included, so restarts never duplicate the
+// catalog. Seeded books have no audit entries: they were not created by anyone.
+func Seed(ctx context.Context, db *sql.DB) (int, error) {
+	if len(seedBooks) == 0 {
+		return 0, nil
+	}
+	var inserted int
+	err := WithTx(ctx, db, func(tx *sql.Tx) error {
+		inserted = 0
+		var exists bool
+		if err := tx.QueryRowContext(ctx, fmt.Sprintf("SELECT EXISTS(SELECT 1 FROM %s)", Table(Books))).Scan(&exists); err != nil {
+			return err
+		}
+		if exists {
+			return nil
+		}
+		for start := 0; start < len(seedBooks); start += seedBatchSize {
+			batch := seedBooks[start:min(start+seedBatchSize, len(seedBooks))]
+			placeholders := make([]string, len(batch))
+			args := make([]any, 0, 4*len(batch))
+			for i, book := range batch {
+				placeholders[i] = "(?, ?, ?, ?)"
+				args = append(args, book.Title, book.Author, book.Year, book.CoverURL)
+			}
+			query := fmt.Sprintf("INSERT INTO %s (`title`, `author`, `publication_year`, `cover_url`) VALUES ", Table(Books)) + strings.Join(placeholders, ", ")
+			if _, err := tx.ExecContext(ctx, query, args...); err != nil {
+				return err
+			}
+			inserted += len(batch)
+		}
+		return nil
+	})
+	return inserted, err
+}
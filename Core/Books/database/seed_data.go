@@ -0,0 +1,87 @@
+//go:build seed
+
+package database
+
+import "golang-api-rest-swagger/Core/Books/models"
+
+// The fixture catalog of development builds: well-known novels, spread over two centuries and
+// several books per author, so listing, filtering, search and the statistics have data to show.
+func init() {
+	seedBooks = []models.Book{
+		{Title: "Pride and Prejudice", Author: "Jane Austen", Year: 1813},
+		{Title: "Sense and Sensibility", Author: "Jane Austen", Year: 1811},
+		{Title: "Emma", Author: "Jane Austen", Year: 1815},
+		{Title: "Persuasion", Author: "Jane Austen", Year: 1817},
+		{Title: "Frankenstein", Author: "Mary Shelley", Year: 1818},
+		{Title: "Jane Eyre", Author: "Charlotte Brontë", Year: 1847},
+		{Title: "Wuthering Heights", Author: "Emily Brontë", Year: 1847},
+		{Title: "Moby-Dick", Author: "Herman Melville", Year: 1851},
+		{Title: "Bleak House", Author: "Charles Dickens", Year: 1853},
+		{Title: "A Tale of Two Cities", Author: "Charles Dickens", Year: 1859},
+		{Title: "Great Expectations", Author: "Charles Dickens", Year: 1861},
+		{Title: "Madame Bovary", Author: "Gustave Flaubert", Year: 1856},
+		{Title: "Les Misérables", Author: "Victor Hugo", Year: 1862},
+		{Title: "Crime and Punishment", Author: "Fyodor Dostoevsky", Year: 1866},
+		{Title: "The Brothers Karamazov", Author: "Fyodor Dostoevsky", Year: 1880},
+		{Title: "War and Peace", Author: "Leo Tolstoy", Year: 1869},
+		{Title: "Anna Karenina", Author: "Leo Tolstoy", Year: 1878},
+		{Title: "Middlemarch", Author: "George Eliot", Year: 1871},
+		{Title: "Alice's Adventures in Wonderland", Author: "Lewis Carroll", Year: 1865},
+		{Title: "Twenty Thousand Leagues Under the Seas", Author: "Jules Verne", Year: 1870},
+		{Title: "Around the World in Eighty Days", Author: "Jules Verne", Year: 1872},
+		{Title: "The Adventures of Tom Sawyer", Author: "Mark Twain", Year: 1876},
+		{Title: "Adventures of Huckleberry Finn", Author: "Mark Twain", Year: 1884},
+		{Title: "Treasure Island", Author: "Robert Louis Stevenson", Year: 1883},
+		{Title: "Strange Case of Dr Jekyll and Mr Hyde", Author: "Robert Louis Stevenson", Year: 1886},
+		{Title: "The Picture of Dorian Gray", Author: "Oscar Wilde", Year: 1890},
+		{Title: "Dracula", Author: "Bram Stoker", Year: 1897},
+		{Title: "The Time Machine", Author: "H. G. Wells", Year: 1895},
+		{Title: "The War of the Worlds", Author: "H. G. Wells", Year: 1898},
+		{Title: "Heart of Darkness", Author: "Joseph Conrad", Year: 1899},
+		{Title: "The Hound of the Baskervilles", Author: "Arthur Conan Doyle", Year: 1902},
+		{Title: "The Call of the Wild", Author: "Jack London", Year: 1903},
+		{Title: "Ulysses", Author: "James Joyce", Year: 1922},
+		{Title: "Mrs Dalloway", Author: "Virginia Woolf", Year: 1925},
+		{Title: "To the Lighthouse", Author: "Virginia Woolf", Year: 1927},
+		{Title: "The Great Gatsby", Author: "F. Scott Fitzgerald", Year: 1925},
+		{Title: "The Trial", Author: "Franz Kafka", Year: 1925},
+		{Title: "The Sun Also Rises", Author: "Ernest Hemingway", Year: 1926},
+		{Title: "The Sound and the Fury", Author: "William Faulkner", Year: 1929},
+		{Title: "Brave New World", Author: "Aldous Huxley", Year: 1932},
+		{Title: "The Hobbit", Author: "J. R. R. Tolkien", Year: 1937},
+		{Title: "The Fellowship of the Ring", Author: "J. R. R. Tolkien", Year: 1954},
+		{Title: "The Two Towers", Author: "J. R. R. Tolkien", Year: 1954},
+		{Title: "The Return of the King", Author: "J. R. R. Tolkien", Year: 1955},
+		{Title: "The Grapes of Wrath", Author: "John Steinbeck", Year: 1939},
+		{Title: "Of Mice and Men", Author: "John Steinbeck", Year: 1937},
+		{Title: "The Stranger", Author: "Albert Camus", Year: 1942},
+		{Title: "The Little Prince", Author: "Antoine de Saint-Exupéry", Year: 1943},
+		{Title: "Animal Farm", Author: "George Orwell", Year: 1945},
+		{Title: "Nineteen Eighty-Four", Author: "George Orwell", Year: 1949},
+		{Title: "The Catcher in the Rye", Author: "J. D. Salinger", Year: 1951},
+		{Title: "Fahrenheit 451", Author: "Ray Bradbury", Year: 1953},
+		{Title: "Lord of the Flies", Author: "William Golding", Year: 1954},
+		{Title: "Lolita", Author: "Vladimir Nabokov", Year: 1955},
+		{Title: "On the Road", Author: "Jack Kerouac", Year: 1957},
+		{Title: "Things Fall Apart", Author: "Chinua Achebe", Year: 1958},
+		{Title: "To Kill a Mockingbird", Author: "Harper Lee", Year: 1960},
+		{Title: "Catch-22", Author: "Joseph Heller", Year: 1961},
+		{Title: "Dune", Author: "Frank Herbert", Year: 1965},
+		{Title: "One Hundred Years of Solitude", Author: "Gabriel García Márquez", Year: 1967},
+		{Title: "Slaughterhouse-Five", Author: "Kurt Vonnegut", Year: 1969},
+		{Title: "The Left Hand of Darkness", Author: "Ursula K. Le Guin", Year: 1969},
+		{Title: "A Wizard of Earthsea", Author: "Ursula K. Le Guin", Year: 1968},
+		{Title: "Invisible Cities", Author: "Italo Calvino", Year: 1972},
+		{Title: "The Hitchhiker's Guide to the Galaxy", Author: "Douglas Adams", Year: 1979},
+		{Title: "The Name of the Rose", Author: "Umberto Eco", Year: 1980},
+		{Title: "Midnight's Children", Author: "Salman Rushdie", Year: 1981},
+		{Title: "Neuromancer", Author: "William Gibson", Year: 1984},
+		{Title: "The Handmaid's Tale", Author: "Margaret Atwood", Year: 1985},
+		{Title: "Beloved", Author: "Toni Morrison", Year: 1987},
+		{Title: "Norwegian Wood", Author: "Haruki Murakami", Year: 1987},
+		{Title: "The Remains of the Day", Author: "Kazuo Ishiguro", Year: 1989},
+		{Title: "Never Let Me Go", Author: "Kazuo Ishiguro", Year: 2005},
+		{Title: "The Road", Author: "Cormac McCarthy", Year: 2006},
+		{Title: "Wolf Hall", Author: "Hilary Mantel", Year: 2009},
+	}
+}
@@ -0,0 +1,61 @@
+package database
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"github.com/go-sql-driver/mysql"
+	"log"
+	"math/rand/v2"
+	"time"
+)
+
+// erDeadlock is the MySQL error number returned when a transaction is chosen as a deadlock victim.
+const erDeadlock = 1213
+
+// maxTxAttempts is the number of times a transaction is run before a deadlock is surfaced to the caller.
+const maxTxAttempts = 3
+
+// WithTx runs fn inside a transaction, committing it when fn succeeds and rolling it back otherwise.
+// Errors returned by fn are passed through unchanged so callers can inspect them, e.g. for sql.ErrNoRows.
+// When MySQL aborts the transaction because of a deadlock, it is retried from the start after a short
+// randomized backoff, so fn must not keep state from an earlier attempt.
+func WithTx(ctx context.Context, db *sql.DB, fn func(tx *sql.Tx) error) error {
+	for attempt := 1; ; attempt++ {
+		err := runTx(ctx, db, fn)
+		if !isDeadlock(err) || attempt == maxTxAttempts {
+			return err
+		}
+		// Back off 10-50ms, growing with each attempt, so the competing transactions do not collide again.
+		backoff := time.Duration(attempt) * (10*time.Millisecond + rand.N(40*time.Millisecond))
+		log.Printf("Transaction deadlocked (attempt %d of %d), retrying in %s", attempt, maxTxAttempts, backoff)
+		select {
+		case <-time.After(backoff):
+		case <-ctx.Done():
+			return err
+		}
+	}
+}
+
+// runTx runs a single attempt of WithTx.
+func runTx(ctx context.Context, db *sql.DB, fn func(tx *sql.Tx) error) error {
+	tx, err := db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	if err := fn(tx); err != nil {
+		tx.Rollback()
+		return err
+	}
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit transaction: %w", err)
+	}
+	return nil
+}
+
+// isDeadlock reports whether err is a MySQL deadlock error.
+func isDeadlock(err error) bool {
+	var mysqlErr *mysql.MySQLError
+	return errors.As(err, &mysqlErr) && mysqlErr.Number == erDeadlock
+}
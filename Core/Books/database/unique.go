@@ -0,0 +1,104 @@
+package database
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"github.com/go-sql-driver/mysql"
+	"log"
+	"strings"
+)
+
+// erDupEntry is the MySQL error number returned when a write would duplicate a unique key.
+const erDupEntry = 1062
+
+// Names of the unique keys of the books table, as returned by DuplicateKey.
+const (
+	UniqueISBN = "uq_books_isbn"
+	// UniqueTitleAuthor only exists when ENFORCE_UNIQUE_TITLE_AUTHOR is enabled.
+	UniqueTitleAuthor = "uq_books_title_author"
+)
+
+// DuplicateKey returns the name of the unique key that err, a MySQL error, says a write would
+// duplicate, or "" when err is not such an error.
+func DuplicateKey(err error) string {
+	var mysqlErr *mysql.MySQLError
+	if !errors.As(err, &mysqlErr) || mysqlErr.Number != erDupEntry {
+		return ""
+	}
+	// The message ends with "for key 'uq_books_isbn'", the key prefixed with its table since MySQL 8.0.19.
+	_, key, ok := strings.Cut(mysqlErr.Message, "for key '")
+	if !ok {
+		return "unknown"
+	}
+	key = strings.TrimSuffix(key, "'")
+	if i := strings.LastIndex(key, "."); i >= 0 {
+		key = key[i+1:]
+	}
+	return key
+}
+
+// hasUniqueTitleAuthor reports whether the unique key on the title and author of books exists.
+func hasUniqueTitleAuthor(db *sql.DB) (bool, error) {
+	var exists bool
+	err := db.QueryRow("SELECT COUNT(*) > 0 FROM information_schema.STATISTICS WHERE `TABLE_SCHEMA` = DATABASE() AND `TABLE_NAME` = ? AND `INDEX_NAME` = ?",
+		tablePrefix+Books, UniqueTitleAuthor).Scan(&exists)
+	if err != nil {
+		return false, fmt.Errorf("failed to look up the unique title and author key: %v", err)
+	}
+	return exists, nil
+}
+
+// checkUniqueTitleAuthor fails when the unique key on the title and author of books does not
+// follow enforce, for startups leaving the schema to another step.
+func checkUniqueTitleAuthor(db *sql.DB, enforce bool) error {
+	exists, err := hasUniqueTitleAuthor(db)
+	if err != nil {
+		return err
+	}
+	switch {
+	case enforce && !exists:
+		return fmt.Errorf("ENFORCE_UNIQUE_TITLE_AUTHOR is set but the unique title and author key %s is missing: add it, or set RUN_MIGRATIONS=true", UniqueTitleAuthor)
+	case !enforce && exists:
+		return fmt.Errorf("ENFORCE_UNIQUE_TITLE_AUTHOR is not set but the unique title and author key %s exists: drop it, or set RUN_MIGRATIONS=true", UniqueTitleAuthor)
+	}
+	return nil
+}
+
+// syncUniqueTitleAuthor creates the unique key on the title and author of books when enforce is
+// set, and drops it otherwise. It follows the configuration rather than the schema version, so
+// ENFORCE_UNIQUE_TITLE_AUTHOR can be turned on and off.
+func syncUniqueTitleAuthor(db *sql.DB, enforce bool) error {
+	exists, err := hasUniqueTitleAuthor(db)
+	if err != nil {
+		return err
+	}
+
+	switch {
+	case enforce && !exists:
+		_, err := db.Exec(fmt.Sprintf("CREATE UNIQUE INDEX %s ON %s (`title`, `author`)", QuoteIdent(UniqueTitleAuthor), Table(Books)))
+		switch {
+		case DuplicateKey(err) != "":
+			return fmt.Errorf("cannot enforce unique titles and authors, some books share both (see GET /books/duplicates): %v", err)
+		case err != nil && !alreadyApplied(err):
+			return fmt.Errorf("failed to add the unique title and author key: %v", err)
+		}
+		log.Println("Added the unique key on the title and author of books")
+	case !enforce && exists:
+		if _, err := db.Exec(fmt.Sprintf("DROP INDEX %s ON %s", QuoteIdent(UniqueTitleAuthor), Table(Books))); err != nil && !isMissingKey(err) {
+			return fmt.Errorf("failed to drop the unique title and author key: %v", err)
+		}
+		log.Println("Dropped the unique key on the title and author of books")
+	}
+	return nil
+}
+
+// erCantDropFieldOrKey is the MySQL error number returned when the key to drop does not exist,
+// typically because another instance booting at the same time dropped it first.
+const erCantDropFieldOrKey = 1091
+
+// isMissingKey reports whether err says the key to drop does not exist.
+func isMissingKey(err error) bool {
+	var mysqlErr *mysql.MySQLError
+	return errors.As(err, &mysqlErr) && mysqlErr.Number == erCantDropFieldOrKey
+}
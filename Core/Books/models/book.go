@@ -1,9 +1,65 @@
 package models
 
+import (
+	"golang-api-rest-swagger/Core/Shared/timestamp"
+	"time"
+)
+
+// Sizes of the title and author VARCHAR columns, which are the max rules of Book. The configured
+// limits (MAX_TITLE_LEN, MAX_AUTHOR_LEN) can only lower them. Changing one takes a migration
+// resizing the column as well.
+const (
+	TitleColumnLength  = 255
+	AuthorColumnLength = 255
+)
+
+// The max rules of the title and author come from the column sizes, so they cannot drift apart.
+func init() {
+	if err := SetMaxLength(Book{}, "title", TitleColumnLength); err != nil {
+		panic(err)
+	}
+	if err := SetMaxLength(Book{}, "author", AuthorColumnLength); err != nil {
+		panic(err)
+	}
+}
+
 // Book struct to hold book details.
 type Book struct {
-	ID     int    `json:"id" db:"id"`
-	Title  string `json:"title" db:"title"`
-	Author string `json:"author" db:"author"`
-	Year   int    `json:"year" db:"year"`
+	ID     int64  `json:"id" db:"id" example:"1"`
+	Title  string `json:"title" db:"title" validate:"required" example:"The Hobbit"`
+	Author string `json:"author" db:"author" validate:"required" example:"J. R. R. Tolkien"`
+	Year   int    `json:"year" db:"publication_year" validate:"required" example:"1937"`
+	// CoverURL is the optional address of the cover image, empty when there is none.
+	CoverURL string `json:"cover_url" db:"cover_url" validate:"max=500,url" example:"https://example.com/hobbit.jpg"`
+	// Notes are internal remarks on the book, e.g. on the copy held, shown to and written by admins only.
+	Notes string `json:"notes" db:"notes" validate:"max=1000" visibility:"admin" example:"Signed first edition"`
+	// ISBN is the optional ISBN-10 or ISBN-13, digits only, empty when unknown. It is unique.
+	ISBN string `json:"isbn" db:"isbn" validate:"isbn" example:"9780261102217"`
+	// CreatedAt and UpdatedAt are kept by the database and written in the TIME_FORMAT. They are only
+	// returned by the reads of books, GET /books and GET /books/{id}, and are never written by clients.
+	CreatedAt *timestamp.Time `json:"created_at,omitempty" db:"created_at" swaggertype:"string" example:"2024-05-01T12:00:00Z"`
+	UpdatedAt *timestamp.Time `json:"updated_at,omitempty" db:"updated_at" swaggertype:"string" example:"2024-05-02T08:30:00.123456Z"`
+}
+
+// BookInput documents the body of the requests creating, replacing or validating a book: a
+// Book without the id, which the server assigns or takes from the path. Handlers decode a Book;
+// this type only exists for the Swagger examples.
+type BookInput struct {
+	Title    string `json:"title" example:"The Hobbit"`
+	Author   string `json:"author" example:"J. R. R. Tolkien"`
+	Year     int    `json:"year" example:"1937"`
+	CoverURL string `json:"cover_url" example:"https://example.com/hobbit.jpg"`
+	// Notes are only written when an admin sends the request.
+	Notes string `json:"notes" example:"Signed first edition"`
+	ISBN  string `json:"isbn" example:"9780261102217"`
+}
+
+// ApplyCreateDefaults fills in the fields a client may omit when creating a book:
+//   - a missing (zero) year defaults to the current year, in UTC.
+//
+// It runs before validation, so defaulted fields always satisfy their required rule.
+func (b *Book) ApplyCreateDefaults(now time.Time) {
+	if b.Year == 0 {
+		b.Year = now.UTC().Year()
+	}
 }
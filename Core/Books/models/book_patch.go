@@ -0,0 +1,48 @@
+package models
+
+import "fmt"
+
+// BookPatch is a partial update of a book. Absent fields are left unchanged, null clears an
+// optional field, and any other value replaces the current one.
+type BookPatch struct {
+	Title    Optional[string] `json:"title" swaggertype:"string" example:"The Hobbit"`
+	Author   Optional[string] `json:"author" swaggertype:"string" example:"J. R. R. Tolkien"`
+	Year     Optional[int]    `json:"year" swaggertype:"integer" example:"1937"`
+	CoverURL Optional[string] `json:"cover_url" swaggertype:"string" example:"https://example.com/hobbit.jpg"`
+	Notes    Optional[string] `json:"notes" swaggertype:"string" example:"Signed first edition"`
+	ISBN     Optional[string] `json:"isbn" swaggertype:"string" example:"9780261102217"`
+}
+
+// Apply returns book with the patch applied. Required fields cannot be cleared: a null for one
+// of them is reported as a field error. The result still has to be validated.
+func (p BookPatch) Apply(book Book) (Book, FieldErrors) {
+	var errs FieldErrors
+	required := func(name string, null bool) bool {
+		if null {
+			errs = append(errs, FieldError{Field: name, Message: fmt.Sprintf("%s cannot be null", name)})
+		}
+		return !null
+	}
+
+	if p.Title.Set && required("title", p.Title.Null) {
+		book.Title = p.Title.Value
+	}
+	if p.Author.Set && required("author", p.Author.Null) {
+		book.Author = p.Author.Value
+	}
+	if p.Year.Set && required("year", p.Year.Null) {
+		book.Year = p.Year.Value
+	}
+	if p.CoverURL.Set {
+		// Null and "" both mean no cover.
+		book.CoverURL = p.CoverURL.Value
+	}
+	if p.Notes.Set {
+		// Null and "" both mean no notes.
+		book.Notes = p.Notes.Value
+	}
+	if p.ISBN.Set {
+		book.ISBN = p.ISBN.Value
+	}
+	return book, errs
+}
@@ -0,0 +1,133 @@
+package models
+
+import (
+	"encoding/json"
+	"golang-api-rest-swagger/Core/Shared/middleware"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+// patchMediaTypes are the content types a PATCH body may be sent as, which must not change how
+// it is applied.
+var patchMediaTypes = []string{"application/json", "application/merge-patch+json"}
+
+// applyPatch sends body as a PATCH with the given content type through RequireJSON, then decodes
+// and applies it to stored, as PatchBook does.
+func applyPatch(t *testing.T, contentType, body string, stored Book) (Book, FieldErrors) {
+	t.Helper()
+	var patched Book
+	var errs FieldErrors
+	reached := false
+	handler := middleware.RequireJSON(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		reached = true
+		var patch BookPatch
+		if err := json.NewDecoder(r.Body).Decode(&patch); err != nil {
+			t.Fatalf("decoding %s failed: %v", body, err)
+		}
+		patched, errs = patch.Apply(stored)
+	}))
+	r := httptest.NewRequest(http.MethodPatch, "/books/1", strings.NewReader(body))
+	r.Header.Set("Content-Type", contentType)
+	w := httptest.NewRecorder()
+	handler.ServeHTTP(w, r)
+	if !reached {
+		t.Fatalf("PATCH sent as %s was refused with %d: %s", contentType, w.Code, w.Body.String())
+	}
+	return patched, errs
+}
+
+func TestBookPatchApply(t *testing.T) {
+	stored := Book{ID: 1, Title: "The Hobbit", Author: "J. R. R. Tolkien", Year: 1937, CoverURL: "https://example.com/hobbit.jpg", Notes: "Signed", ISBN: "9780261102217"}
+	tests := []struct {
+		name string
+		body string
+		want Book
+		errs []string
+	}{
+		{
+			name: "absent fields are left unchanged",
+			body: `{}`,
+			want: stored,
+		},
+		{
+			name: "absent fields are left unchanged around a set one",
+			body: `{"year": 1938}`,
+			want: Book{ID: 1, Title: "The Hobbit", Author: "J. R. R. Tolkien", Year: 1938, CoverURL: "https://example.com/hobbit.jpg", Notes: "Signed", ISBN: "9780261102217"},
+		},
+		{
+			name: "null clears an optional field",
+			body: `{"cover_url": null}`,
+			want: Book{ID: 1, Title: "The Hobbit", Author: "J. R. R. Tolkien", Year: 1937, Notes: "Signed", ISBN: "9780261102217"},
+		},
+		{
+			name: "null clears every optional field",
+			body: `{"cover_url": null, "notes": null, "isbn": null}`,
+			want: Book{ID: 1, Title: "The Hobbit", Author: "J. R. R. Tolkien", Year: 1937},
+		},
+		{
+			name: "null for a required field is an error",
+			body: `{"title": null}`,
+			want: stored,
+			errs: []string{"title"},
+		},
+		{
+			name: "null for every required field is an error for each",
+			body: `{"title": null, "author": null, "year": null}`,
+			want: stored,
+			errs: []string{"title", "author", "year"},
+		},
+		{
+			name: "set values replace the stored ones",
+			body: `{"title": "The Lord of the Rings", "author": "Tolkien", "cover_url": "https://example.com/lotr.jpg", "isbn": "9780261103252"}`,
+			want: Book{ID: 1, Title: "The Lord of the Rings", Author: "Tolkien", Year: 1937, CoverURL: "https://example.com/lotr.jpg", Notes: "Signed", ISBN: "9780261103252"},
+		},
+		{
+			name: "an empty string is a value, not an absent field",
+			body: `{"notes": ""}`,
+			want: Book{ID: 1, Title: "The Hobbit", Author: "J. R. R. Tolkien", Year: 1937, CoverURL: "https://example.com/hobbit.jpg", ISBN: "9780261102217"},
+		},
+	}
+	for _, contentType := range patchMediaTypes {
+		for _, tt := range tests {
+			t.Run(contentType+"/"+tt.name, func(t *testing.T) {
+				got, errs := applyPatch(t, contentType, tt.body, stored)
+				if len(tt.errs) == 0 && got != tt.want {
+					t.Errorf("Apply(%s) = %+v, want %+v", tt.body, got, tt.want)
+				}
+				if len(errs) != len(tt.errs) {
+					t.Fatalf("Apply(%s) returned the errors %v, want errors for %v", tt.body, errs, tt.errs)
+				}
+				for i, field := range tt.errs {
+					if errs[i].Field != field {
+						t.Errorf("Apply(%s) error %d is for %s, want %s", tt.body, i, errs[i].Field, field)
+					}
+				}
+			})
+		}
+	}
+}
+
+func TestOptionalUnmarshalJSON(t *testing.T) {
+	tests := []struct {
+		body string
+		want Optional[string]
+	}{
+		{`{}`, Optional[string]{}},
+		{`{"title": null}`, Optional[string]{Set: true, Null: true}},
+		{`{"title": ""}`, Optional[string]{Set: true}},
+		{`{"title": "Dune"}`, Optional[string]{Set: true, Value: "Dune"}},
+	}
+	for _, tt := range tests {
+		var v struct {
+			Title Optional[string] `json:"title"`
+		}
+		if err := json.Unmarshal([]byte(tt.body), &v); err != nil {
+			t.Fatalf("decoding %s failed: %v", tt.body, err)
+		}
+		if v.Title != tt.want {
+			t.Errorf("decoding %s = %+v, want %+v", tt.body, v.Title, tt.want)
+		}
+	}
+}
@@ -0,0 +1,9 @@
+package models
+
+// DuplicateGroup is a set of books sharing the same normalized title and author.
+type DuplicateGroup struct {
+	Title  string  `json:"title" example:"the hobbit"`
+	Author string  `json:"author" example:"j. r. r. tolkien"`
+	Count  int     `json:"count" example:"2"`
+	IDs    []int64 `json:"ids" example:"4,17"`
+}
@@ -0,0 +1,8 @@
+package models
+
+// LetterCount is the number of books whose title starts with a letter.
+type LetterCount struct {
+	// Letter is an uppercase letter, or # for titles starting with anything else.
+	Letter string `json:"letter" example:"A"`
+	Count  int    `json:"count" example:"12"`
+}
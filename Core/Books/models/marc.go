@@ -0,0 +1,62 @@
+package models
+
+import "strconv"
+
+// marcLeader is the leader of every MARC record: a new (n) record of language material (a), a
+// monograph (m), in Unicode (a), with minimal encoding (7) and ISBD punctuation omitted (u). The
+// record length and base address are left at zero, as they only make sense in the binary format.
+const marcLeader = "00000nam a22000007u 4500"
+
+// MARCRecord is a book as a simplified MARC 21 bibliographic record, in the MARC-in-JSON layout
+// read by library systems: the leader, then the fields in tag order, each an object keyed by its tag.
+type MARCRecord struct {
+	Leader string      `json:"leader" example:"00000nam a22000007u 4500"`
+	Fields []MARCField `json:"fields"`
+}
+
+// MARCField is a field of a MARCRecord keyed by its tag: a control field, below 010, holds its
+// value as a string, a data field a MARCDataField.
+type MARCField map[string]any
+
+// MARCDataField is the content of a data field: its two indicators, blank when undefined, and its
+// subfields, each an object keyed by its code.
+type MARCDataField struct {
+	Ind1      string              `json:"ind1" example:"1"`
+	Ind2      string              `json:"ind2" example:"0"`
+	Subfields []map[string]string `json:"subfields"`
+}
+
+// NewMARCRecord maps a book to a MARC record:
+//   - 001, the control number, is the id;
+//   - 020 $a is the ISBN, when set;
+//   - 100 $a, the main entry, is the author, as a personal name;
+//   - 245 $a is the title;
+//   - 260 $c is the publication year;
+//   - 856 $u is the cover URL, when set, as a related resource.
+//
+// The notes are internal to the catalog and are not mapped.
+func NewMARCRecord(b Book) MARCRecord {
+	fields := []MARCField{{"001": strconv.FormatInt(b.ID, 10)}}
+	if b.ISBN != "" {
+		fields = append(fields, marcDataField("020", " ", " ", "a", b.ISBN))
+	}
+	fields = append(fields,
+		marcDataField("100", "1", " ", "a", b.Author),
+		// No title added entry beyond the main entry, and no leading article to skip when filing.
+		marcDataField("245", "1", "0", "a", b.Title),
+		marcDataField("260", " ", " ", "c", strconv.Itoa(b.Year)),
+	)
+	if b.CoverURL != "" {
+		fields = append(fields, marcDataField("856", "4", "2", "3", "Cover image", "u", b.CoverURL))
+	}
+	return MARCRecord{Leader: marcLeader, Fields: fields}
+}
+
+// marcDataField returns the data field tag with the given indicators and code, value pairs of subfields.
+func marcDataField(tag, ind1, ind2 string, subfields ...string) MARCField {
+	field := MARCDataField{Ind1: ind1, Ind2: ind2}
+	for i := 0; i+1 < len(subfields); i += 2 {
+		field.Subfields = append(field.Subfields, map[string]string{subfields[i]: subfields[i+1]})
+	}
+	return MARCField{tag: field}
+}
@@ -0,0 +1,25 @@
+package models
+
+import "encoding/json"
+
+// Optional is a field of a partial update that tells apart the three states a client can send:
+// absent (Set is false), null (Set and Null are true) and a value.
+type Optional[T any] struct {
+	Set   bool
+	Null  bool
+	Value T
+}
+
+// UnmarshalJSON records that the field was present. It is not called for absent fields, which
+// is what leaves Set false.
+func (o *Optional[T]) UnmarshalJSON(data []byte) error {
+	o.Set = true
+	if string(data) == "null" {
+		o.Null = true
+		var zero T
+		o.Value = zero
+		return nil
+	}
+	o.Null = false
+	return json.Unmarshal(data, &o.Value)
+}
@@ -0,0 +1,80 @@
+package models
+
+import (
+	"reflect"
+)
+
+// Schema describes the fields of a model, so clients can build forms dynamically.
+type Schema struct {
+	Name   string        `json:"name" example:"book"`
+	Fields []SchemaField `json:"fields"`
+}
+
+// SchemaField describes a single field of a model.
+type SchemaField struct {
+	Name      string `json:"name" example:"title"`
+	Type      string `json:"type" example:"string"`
+	Required  bool   `json:"required" example:"true"`
+	MaxLength int    `json:"max_length,omitempty" example:"255"`
+	// Format refines the type, e.g. uri for URL fields.
+	Format string `json:"format,omitempty" example:"uri"`
+}
+
+// Describe builds the schema of v, a struct, from its json and validate struct tags.
+// It reads the same rules as Validate, so the schema always matches the validation rules.
+func Describe(name string, v any) Schema {
+	schema := Schema{Name: name, Fields: []SchemaField{}}
+	t := reflect.Indirect(reflect.ValueOf(v)).Type()
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		fieldName := jsonName(field)
+		if fieldName == "" {
+			continue
+		}
+		r := fieldRules(t, field)
+		sf := SchemaField{
+			Name:      fieldName,
+			Type:      fieldType(field),
+			Required:  r.required,
+			MaxLength: r.maxLength,
+		}
+		if r.url {
+			sf.Format = "uri"
+		}
+		if r.isbn {
+			sf.Format = "isbn"
+		}
+		schema.Fields = append(schema.Fields, sf)
+	}
+	return schema
+}
+
+// fieldType returns the JSON type of a field: the one of its swaggertype tag, for the types
+// marshaling themselves like times, or the one its Go type is serialized as.
+func fieldType(field reflect.StructField) string {
+	if t := field.Tag.Get("swaggertype"); t != "" {
+		return t
+	}
+	return jsonType(field.Type)
+}
+
+// jsonType returns the JSON type a Go type is serialized as.
+func jsonType(t reflect.Type) string {
+	switch t.Kind() {
+	case reflect.Pointer:
+		return jsonType(t.Elem())
+	case reflect.Bool:
+		return "boolean"
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return "integer"
+	case reflect.Float32, reflect.Float64:
+		return "number"
+	case reflect.Slice, reflect.Array:
+		return "array"
+	case reflect.Struct, reflect.Map:
+		return "object"
+	default:
+		return "string"
+	}
+}
@@ -0,0 +1,178 @@
+package models
+
+import (
+	"fmt"
+	"net/url"
+	"reflect"
+	"strconv"
+	"strings"
+)
+
+// FieldError describes a field that failed validation.
+type FieldError struct {
+	Field   string `json:"field" example:"title"`
+	Message string `json:"message" example:"title is required"`
+}
+
+// FieldErrors is the list of validation failures of a value.
+type FieldErrors []FieldError
+
+// Error joins the failures into a single message.
+func (errs FieldErrors) Error() string {
+	messages := make([]string, len(errs))
+	for i, e := range errs {
+		messages[i] = e.Message
+	}
+	return strings.Join(messages, "; ")
+}
+
+// rules are the validation rules declared in a field's validate struct tag,
+// e.g. `validate:"required,max=255"`.
+type rules struct {
+	required  bool
+	maxLength int
+	// url requires a non-empty string to be an absolute http or https URL.
+	url bool
+	// isbn requires a non-empty string to be an ISBN-10 or ISBN-13 with a valid check digit.
+	isbn bool
+}
+
+// parseRules parses a validate struct tag.
+func parseRules(tag string) rules {
+	var r rules
+	for _, rule := range strings.Split(tag, ",") {
+		name, value, _ := strings.Cut(strings.TrimSpace(rule), "=")
+		switch name {
+		case "required":
+			r.required = true
+		case "max":
+			r.maxLength, _ = strconv.Atoi(value)
+		case "url":
+			r.url = true
+		case "isbn":
+			r.isbn = true
+		}
+	}
+	return r
+}
+
+// maxLengths holds the max rules set by SetMaxLength, by struct type and JSON field name.
+var maxLengths = map[reflect.Type]map[string]int{}
+
+// SetMaxLength sets the max rule of a string field of v's struct type, named by its JSON name, to
+// n. The first call for a field without a max in its struct tag sets the size of its column, from
+// an init function; later ones, typically from configuration, may only lower the max, as may every
+// call for a field with a max tag. It is meant to be called at startup, before any validation runs.
+func SetMaxLength(v any, field string, n int) error {
+	t := reflect.Indirect(reflect.ValueOf(v)).Type()
+	for i := 0; i < t.NumField(); i++ {
+		if jsonName(t.Field(i)) != field {
+			continue
+		}
+		limit := fieldRules(t, t.Field(i)).maxLength
+		if limit == 0 && n >= 1 {
+			limit = n
+		}
+		if n < 1 || n > limit {
+			return fmt.Errorf("the maximum length of %s must be between 1 and %d", field, limit)
+		}
+		if maxLengths[t] == nil {
+			maxLengths[t] = map[string]int{}
+		}
+		maxLengths[t][field] = n
+		return nil
+	}
+	return fmt.Errorf("%s has no field %s", t.Name(), field)
+}
+
+// fieldRules returns the rules of a field of struct type t, with any lowered max applied.
+func fieldRules(t reflect.Type, field reflect.StructField) rules {
+	r := parseRules(field.Tag.Get("validate"))
+	if n, ok := maxLengths[t][jsonName(field)]; ok {
+		r.maxLength = n
+	}
+	return r
+}
+
+// jsonName returns the JSON name of a struct field, or "" when it is not serialized.
+func jsonName(field reflect.StructField) string {
+	name, _, _ := strings.Cut(field.Tag.Get("json"), ",")
+	if name == "-" {
+		return ""
+	}
+	if name == "" {
+		return field.Name
+	}
+	return name
+}
+
+// Validate checks v, a struct, against the rules declared in its validate struct tags.
+// It returns nil when v is valid.
+func Validate(v any) FieldErrors {
+	var errs FieldErrors
+	value := reflect.Indirect(reflect.ValueOf(v))
+	for i := 0; i < value.NumField(); i++ {
+		field := value.Type().Field(i)
+		name := jsonName(field)
+		if name == "" {
+			continue
+		}
+		r := fieldRules(value.Type(), field)
+		fieldValue := value.Field(i)
+
+		if r.required && fieldValue.IsZero() {
+			errs = append(errs, FieldError{Field: name, Message: fmt.Sprintf("%s is required", name)})
+			continue
+		}
+		if r.maxLength > 0 && fieldValue.Kind() == reflect.String && len([]rune(fieldValue.String())) > r.maxLength {
+			errs = append(errs, FieldError{Field: name, Message: fmt.Sprintf("%s must be at most %d characters", name, r.maxLength)})
+			continue
+		}
+		if r.url && fieldValue.Kind() == reflect.String && fieldValue.String() != "" && !isHTTPURL(fieldValue.String()) {
+			errs = append(errs, FieldError{Field: name, Message: fmt.Sprintf("%s must be an http or https URL", name)})
+		}
+		if r.isbn && fieldValue.Kind() == reflect.String && fieldValue.String() != "" && !isISBN(fieldValue.String()) {
+			errs = append(errs, FieldError{Field: name, Message: fmt.Sprintf("%s must be an ISBN-10 or ISBN-13 of digits only, with a valid check digit", name)})
+		}
+	}
+	return errs
+}
+
+// isHTTPURL reports whether s is an absolute http or https URL with a host.
+func isHTTPURL(s string) bool {
+	u, err := url.Parse(s)
+	return err == nil && (u.Scheme == "http" || u.Scheme == "https") && u.Host != ""
+}
+
+// isISBN reports whether s is an ISBN-13, or an ISBN-10 whose check digit may be X, written
+// without hyphens or spaces, with a valid check digit.
+func isISBN(s string) bool {
+	sum := 0
+	switch len(s) {
+	case 10:
+		for i, c := range s {
+			switch {
+			case c >= '0' && c <= '9':
+				sum += int(c-'0') * (10 - i)
+			case c == 'X' && i == 9:
+				sum += 10
+			default:
+				return false
+			}
+		}
+		return sum%11 == 0
+	case 13:
+		for i, c := range s {
+			if c < '0' || c > '9' {
+				return false
+			}
+			weight := 1
+			if i%2 == 1 {
+				weight = 3
+			}
+			sum += int(c-'0') * weight
+		}
+		return sum%10 == 0
+	}
+	return false
+}
@@ -0,0 +1,30 @@
+package models
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestBookMaxLengthsFollowTheColumns(t *testing.T) {
+	for _, tt := range []struct {
+		field  string
+		length int
+		set    func(*Book, string)
+	}{
+		{"title", TitleColumnLength, func(b *Book, s string) { b.Title = s }},
+		{"author", AuthorColumnLength, func(b *Book, s string) { b.Author = s }},
+	} {
+		book := Book{Title: "The Hobbit", Author: "J. R. R. Tolkien", Year: 1937}
+		tt.set(&book, strings.Repeat("é", tt.length))
+		if errs := Validate(book); errs != nil {
+			t.Errorf("a %s of %d characters failed validation: %v", tt.field, tt.length, errs)
+		}
+		tt.set(&book, strings.Repeat("é", tt.length+1))
+		if errs := Validate(book); len(errs) != 1 || errs[0].Field != tt.field {
+			t.Errorf("a %s of %d characters returned the errors %v, want one for %s", tt.field, tt.length+1, errs, tt.field)
+		}
+		if err := SetMaxLength(Book{}, tt.field, tt.length+1); err == nil {
+			t.Errorf("SetMaxLength raised the max of %s above its column", tt.field)
+		}
+	}
+}
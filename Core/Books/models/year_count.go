@@ -0,0 +1,7 @@
+package models
+
+// YearCount is the number of books published in a year.
+type YearCount struct {
+	Year  int `json:"year" example:"1999"`
+	Count int `json:"count" example:"12"`
+}
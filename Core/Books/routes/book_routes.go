@@ -1,31 +1,135 @@
 package routes
 
 import (
-	"database/sql"
 	"github.com/gorilla/mux"
 	"golang-api-rest-swagger/Core/Books/controllers"
+	"golang-api-rest-swagger/Core/Books/database"
+	"golang-api-rest-swagger/Core/Shared/auth"
+	"golang-api-rest-swagger/Core/Shared/config"
+	"golang-api-rest-swagger/Core/Shared/consistency"
+	"golang-api-rest-swagger/Core/Shared/middleware"
 	"net/http"
 )
 
 // SetupRoutes defines the API routes and associates them with the appropriate handler functions.
-func SetupRoutes(r *mux.Router, db *sql.DB) { // Add db as parameter
-	r.HandleFunc("/books", func(w http.ResponseWriter, r *http.Request) {
-		controllers.GetBooks(w, r, db)
+// Reads are served by the replica, writes by the primary, as are the reads of clients that wrote
+// within READ_YOUR_WRITES_SECONDS.
+func SetupRoutes(r *mux.Router, pools database.Pools, cfg config.Config, keys auth.Keys) { // Add db as parameter
+	db := pools.Primary
+
+	// Reads live on their own subrouter so browsers and CDNs may cache them for CACHE_MAX_AGE_SECONDS.
+	reads := r.Methods("GET").Subrouter()
+	reads.Use(middleware.CacheControl(cfg.CacheMaxAge), consistency.Route(pools.ReadYourWrites))
+
+	reads.HandleFunc("/books", func(w http.ResponseWriter, r *http.Request) {
+		controllers.GetBooks(w, r, pools.Reader(r.Context()), cfg.Listing)
+	}).Methods("GET")
+
+	// Registered before /books/{id} so their paths are not taken for an id.
+	reads.HandleFunc("/books/schema", controllers.GetBookSchema).Methods("GET")
+
+	reads.HandleFunc("/books/index", func(w http.ResponseWriter, r *http.Request) {
+		controllers.GetBookIndex(w, r, pools.Reader(r.Context()))
+	}).Methods("GET")
+
+	reads.HandleFunc("/books/feed.xml", func(w http.ResponseWriter, r *http.Request) {
+		controllers.GetBookFeed(w, r, pools.Reader(r.Context()))
+	}).Methods("GET")
+
+	reads.HandleFunc("/books/compare", func(w http.ResponseWriter, r *http.Request) {
+		controllers.CompareBooks(w, r, pools.Reader(r.Context()))
 	}).Methods("GET")
 
-	r.HandleFunc("/books/{id}", func(w http.ResponseWriter, r *http.Request) {
-		controllers.GetBook(w, r, db)
+	reads.HandleFunc("/books/years", func(w http.ResponseWriter, r *http.Request) {
+		controllers.GetBookYears(w, r, pools.Reader(r.Context()))
 	}).Methods("GET")
 
-	r.HandleFunc("/books", func(w http.ResponseWriter, r *http.Request) {
+	if cfg.Features.Enabled(config.FeatureExport) {
+		reads.HandleFunc("/books/export", func(w http.ResponseWriter, r *http.Request) {
+			controllers.ExportBooks(w, r, pools.Reader(r.Context()))
+		}).Methods("GET")
+	}
+
+	// Maintenance analysis, restricted to admins.
+	if cfg.Features.Enabled(config.FeatureDuplicates) {
+		reads.Handle("/books/duplicates", auth.RequireAdmin(keys)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			controllers.GetDuplicateBooks(w, r, pools.Reader(r.Context()), cfg.Listing)
+		}))).Methods("GET")
+	}
+
+	// Also registered before /books/{id}, whose id would otherwise take the extension.
+	reads.HandleFunc("/books/{id:[0-9]+}.bib", func(w http.ResponseWriter, r *http.Request) {
+		controllers.GetBookBibTeX(w, r, pools.Reader(r.Context()))
+	}).Methods("GET")
+
+	reads.HandleFunc("/books/{id}", func(w http.ResponseWriter, r *http.Request) {
+		controllers.GetBook(w, r, pools.Reader(r.Context()))
+	}).Methods("GET")
+
+	reads.HandleFunc("/books/{id}/exists", func(w http.ResponseWriter, r *http.Request) {
+		controllers.BookExists(w, r, pools.Reader(r.Context()))
+	}).Methods("GET")
+
+	reads.HandleFunc("/books/{id}/similar", func(w http.ResponseWriter, r *http.Request) {
+		controllers.GetSimilarBooks(w, r, pools.Reader(r.Context()), cfg.Listing)
+	}).Methods("GET")
+
+	// Saves nothing, so unlike the writes below it stays available in read-only mode.
+	r.HandleFunc("/books/validate", controllers.ValidateBook).Methods("POST")
+
+	// Mutating routes live on their own subrouter so they can be switched off in read-only mode.
+	writes := r.Methods("POST", "PUT", "PATCH", "DELETE").Subrouter()
+	writes.Use(middleware.ReadOnly, middleware.NoStore, consistency.Track(pools.ReadYourWrites))
+
+	writes.HandleFunc("/books", func(w http.ResponseWriter, r *http.Request) {
 		controllers.CreateBook(w, r, db)
 	}).Methods("POST")
 
-	r.HandleFunc("/books/{id}", func(w http.ResponseWriter, r *http.Request) {
-		controllers.UpdateBook(w, r, db)
+	writes.HandleFunc("/books/bulk", func(w http.ResponseWriter, r *http.Request) {
+		controllers.CreateBooks(w, r, db, cfg.Bulk)
+	}).Methods("POST")
+
+	writes.HandleFunc("/books/upsert", func(w http.ResponseWriter, r *http.Request) {
+		controllers.UpsertBooks(w, r, db, cfg.Bulk)
+	}).Methods("POST")
+
+	// Fetches a URL given by the caller, or takes an uploaded file, restricted to admins.
+	if cfg.Features.Enabled(config.FeatureImport) {
+		writes.Handle("/books/import-url", auth.RequireAdmin(keys)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			controllers.ImportBooksFromURL(w, r, db, cfg.Import, cfg.Bulk)
+		}))).Methods("POST")
+
+		middleware.AllowUpload("/books/import")
+		writes.Handle("/books/import", auth.RequireAdmin(keys)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			controllers.ImportBooks(w, r, db, cfg.Import, cfg.Bulk)
+		}))).Methods("POST")
+	}
+
+	writes.HandleFunc("/books/{id}/clone", func(w http.ResponseWriter, r *http.Request) {
+		controllers.CloneBook(w, r, db)
+	}).Methods("POST")
+
+	writes.HandleFunc("/books/{id}/archive", func(w http.ResponseWriter, r *http.Request) {
+		controllers.ArchiveBook(w, r, db)
+	}).Methods("POST")
+
+	writes.HandleFunc("/books/{id}/restore", func(w http.ResponseWriter, r *http.Request) {
+		controllers.RestoreBook(w, r, db)
+	}).Methods("POST")
+
+	writes.HandleFunc("/books/{id}", func(w http.ResponseWriter, r *http.Request) {
+		controllers.UpdateBook(w, r, db, cfg.PutUpsert)
 	}).Methods("PUT")
 
-	r.HandleFunc("/books/{id}", func(w http.ResponseWriter, r *http.Request) {
+	writes.HandleFunc("/books/{id}", func(w http.ResponseWriter, r *http.Request) {
+		controllers.PatchBook(w, r, db)
+	}).Methods("PATCH")
+
+	writes.HandleFunc("/books/{id}/year", func(w http.ResponseWriter, r *http.Request) {
+		controllers.UpdateBookYear(w, r, db)
+	}).Methods("PATCH")
+
+	writes.HandleFunc("/books/{id}", func(w http.ResponseWriter, r *http.Request) {
 		controllers.DeleteBook(w, r, db)
 	}).Methods("DELETE")
 }
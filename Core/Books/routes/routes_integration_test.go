@@ -0,0 +1,89 @@
+//go:build integration
+
+package routes
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"github.com/gorilla/mux"
+	"golang-api-rest-swagger/Core/Books/database/dbtest"
+	"golang-api-rest-swagger/Core/Books/models"
+	"golang-api-rest-swagger/Core/Shared/auth"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+// newTestServer returns the book routes, on the database of dbtest.Open.
+func newTestServer(t *testing.T) http.Handler {
+	t.Helper()
+	cfg, pools := dbtest.Open(t)
+	r := mux.NewRouter()
+	SetupRoutes(r, pools, cfg, auth.Keys{})
+	return r
+}
+
+// do sends a request with body, JSON encoded unless nil, and returns the response.
+func do(t *testing.T, handler http.Handler, method, target string, body any) *httptest.ResponseRecorder {
+	t.Helper()
+	var payload bytes.Buffer
+	if body != nil {
+		if err := json.NewEncoder(&payload).Encode(body); err != nil {
+			t.Fatalf("encode %s %s body: %v", method, target, err)
+		}
+	}
+	req := httptest.NewRequest(method, target, &payload)
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, req)
+	return rec
+}
+
+// decodeBook decodes the book of a response, failing the test unless its status is want.
+func decodeBook(t *testing.T, rec *httptest.ResponseRecorder, want int) models.Book {
+	t.Helper()
+	if rec.Code != want {
+		t.Fatalf("status = %d, want %d: %s", rec.Code, want, rec.Body)
+	}
+	var book models.Book
+	if err := json.NewDecoder(rec.Body).Decode(&book); err != nil {
+		t.Fatalf("decode book: %v", err)
+	}
+	return book
+}
+
+func TestBookLifecycle(t *testing.T) {
+	handler := newTestServer(t)
+
+	created := decodeBook(t, do(t, handler, "POST", "/books", map[string]any{"title": "Dune", "author": "Frank Herbert", "year": 1965}), http.StatusCreated)
+	if created.ID == 0 {
+		t.Fatalf("created book has no id")
+	}
+	path := fmt.Sprintf("/books/%d", created.ID)
+
+	got := decodeBook(t, do(t, handler, "GET", path, nil), http.StatusOK)
+	if got.Title != "Dune" || got.Author != "Frank Herbert" || got.Year != 1965 {
+		t.Errorf("GET %s = %+v, want the created book", path, got)
+	}
+	if got.CreatedAt == nil || got.UpdatedAt == nil {
+		t.Errorf("GET %s has no timestamps: %+v", path, got)
+	}
+
+	patched := decodeBook(t, do(t, handler, "PATCH", path, map[string]any{"year": 1966}), http.StatusOK)
+	if patched.Year != 1966 || patched.Title != "Dune" {
+		t.Errorf("PATCH %s = %+v, want only the year changed", path, patched)
+	}
+	if got := decodeBook(t, do(t, handler, "GET", path, nil), http.StatusOK); got.Year != 1966 {
+		t.Errorf("GET %s after PATCH has year %d, want 1966", path, got.Year)
+	}
+
+	if rec := do(t, handler, "DELETE", path, nil); rec.Code != http.StatusOK {
+		t.Fatalf("DELETE %s status = %d, want 200: %s", path, rec.Code, rec.Body)
+	}
+	if rec := do(t, handler, "GET", path, nil); rec.Code != http.StatusNotFound {
+		t.Errorf("GET %s after DELETE status = %d, want 404", path, rec.Code)
+	}
+}
@@ -0,0 +1,132 @@
+package controllers
+
+import (
+	"database/sql"
+	"fmt"
+	"github.com/gorilla/mux"
+	"golang-api-rest-swagger/Core/Books/database"
+	"golang-api-rest-swagger/Core/Books/models"
+	"golang-api-rest-swagger/Core/Shared/auth"
+	"golang-api-rest-swagger/Core/Shared/respond"
+	"net/http"
+	"strconv"
+)
+
+// GetFavorites handles the retrieval of the caller's favorite books.
+// @Summary Get favorite books
+// @Description Retrieve the books in the authenticated caller's favorites list
+// @Tags favorites
+// @Produce json
+// @Security ApiKeyAuth
+// @Success 200 {array} models.Book
+// @Failure 401 {string} string "Unauthorized"
+// @Router /favorites [get]
+func GetFavorites(w http.ResponseWriter, r *http.Request, db *sql.DB) {
+	w.Header().Set("Content-Type", "application/json")
+	principal, _ := auth.FromContext(r.Context())
+
+	// Join the favorites with the books so clients get the full book objects.
+	rows, err := db.QueryContext(r.Context(), fmt.Sprintf(
+		"SELECT b.`id`, b.`title`, b.`author`, b.`publication_year`, b.`cover_url`, b.`notes`, COALESCE(b.`isbn`, '') "+
+			"FROM %s f "+
+			"JOIN %s b ON b.`id` = f.`book_id` "+
+			"WHERE f.`subject` = ? AND b.`deleted_at` IS NULL "+
+			"ORDER BY f.`created_at`, b.`id`", database.Table(database.Favorites), database.Table(database.Books)), principal.Subject)
+	if err != nil {
+		respond.Error(w, fmt.Sprintf("Database query failed: %v", err), http.StatusInternalServerError)
+		return
+	}
+	defer rows.Close()
+
+	books := []models.Book{}
+	for rows.Next() {
+		var book models.Book
+		if err := rows.Scan(&book.ID, &book.Title, &book.Author, &book.Year, &book.CoverURL, &book.Notes, &book.ISBN); err != nil {
+			respond.Error(w, fmt.Sprintf("Failed to scan row: %v", err), http.StatusInternalServerError)
+			return
+		}
+		books = append(books, book)
+	}
+
+	if err := rows.Err(); err != nil {
+		respond.Error(w, fmt.Sprintf("Error during row iteration: %v", err), http.StatusInternalServerError)
+		return
+	}
+
+	respond.JSON(w, r, http.StatusOK, books)
+}
+
+// AddFavorite handles adding a book to the caller's favorites list.
+// @Summary Add a favorite book
+// @Description Add a book to the authenticated caller's favorites list. Adding a book twice is a no-op
+// @Tags favorites
+// @Security ApiKeyAuth
+// @Param bookId path int true "Book ID" example(1)
+// @Success 204 "Book added to favorites"
+// @Failure 401 {string} string "Unauthorized"
+// @Failure 404 {string} string "Book not found"
+// @Router /favorites/{bookId} [post]
+func AddFavorite(w http.ResponseWriter, r *http.Request, db *sql.DB) {
+	principal, _ := auth.FromContext(r.Context())
+	bookID, err := strconv.ParseInt(mux.Vars(r)["bookId"], 10, 64)
+	if err != nil {
+		respond.Error(w, "Invalid book ID", http.StatusBadRequest)
+		return
+	}
+
+	// Make sure the book exists before linking it.
+	var exists int
+	err = db.QueryRowContext(r.Context(), fmt.Sprintf("SELECT 1 FROM %s WHERE `id` = ? AND `deleted_at` IS NULL", database.Table(database.Books)), bookID).Scan(&exists)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			respond.Error(w, "Book not found", http.StatusNotFound)
+			return
+		}
+		respond.Error(w, fmt.Sprintf("Database query failed: %v", err), http.StatusInternalServerError)
+		return
+	}
+
+	_, err = db.ExecContext(r.Context(), fmt.Sprintf("INSERT IGNORE INTO %s (`subject`, `book_id`) VALUES (?, ?)", database.Table(database.Favorites)), principal.Subject, bookID)
+	if err != nil {
+		respond.Error(w, fmt.Sprintf("Database insert failed: %v", err), http.StatusInternalServerError)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// RemoveFavorite handles removing a book from the caller's favorites list.
+// @Summary Remove a favorite book
+// @Description Remove a book from the authenticated caller's favorites list
+// @Tags favorites
+// @Security ApiKeyAuth
+// @Param bookId path int true "Book ID" example(1)
+// @Success 204 "Book removed from favorites"
+// @Failure 401 {string} string "Unauthorized"
+// @Failure 404 {string} string "Favorite not found"
+// @Router /favorites/{bookId} [delete]
+func RemoveFavorite(w http.ResponseWriter, r *http.Request, db *sql.DB) {
+	principal, _ := auth.FromContext(r.Context())
+	bookID, err := strconv.ParseInt(mux.Vars(r)["bookId"], 10, 64)
+	if err != nil {
+		respond.Error(w, "Invalid book ID", http.StatusBadRequest)
+		return
+	}
+
+	result, err := db.ExecContext(r.Context(), fmt.Sprintf("DELETE FROM %s WHERE `subject` = ? AND `book_id` = ?", database.Table(database.Favorites)), principal.Subject, bookID)
+	if err != nil {
+		respond.Error(w, fmt.Sprintf("Database delete failed: %v", err), http.StatusInternalServerError)
+		return
+	}
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		respond.Error(w, fmt.Sprintf("Failed to get number of deleted rows: %v", err), http.StatusInternalServerError)
+		return
+	}
+	if rowsAffected == 0 {
+		respond.Error(w, "Favorite not found", http.StatusNotFound)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
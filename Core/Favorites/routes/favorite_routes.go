@@ -0,0 +1,37 @@
+package routes
+
+import (
+	"github.com/gorilla/mux"
+	"golang-api-rest-swagger/Core/Books/database"
+	"golang-api-rest-swagger/Core/Favorites/controllers"
+	"golang-api-rest-swagger/Core/Shared/auth"
+	"golang-api-rest-swagger/Core/Shared/consistency"
+	"golang-api-rest-swagger/Core/Shared/middleware"
+	"net/http"
+)
+
+// SetupRoutes defines the favorites routes. Every route requires an API key, since the
+// favorites list belongs to the authenticated caller.
+func SetupRoutes(r *mux.Router, pools database.Pools, keys auth.Keys) {
+	db := pools.Primary
+	favorites := r.PathPrefix("/favorites").Subrouter()
+	favorites.Use(auth.Require(keys))
+
+	reads := favorites.Methods("GET").Subrouter()
+	reads.Use(consistency.Route(pools.ReadYourWrites))
+
+	reads.HandleFunc("", func(w http.ResponseWriter, r *http.Request) {
+		controllers.GetFavorites(w, r, pools.Reader(r.Context()))
+	}).Methods("GET")
+
+	writes := favorites.Methods("POST", "DELETE").Subrouter()
+	writes.Use(middleware.ReadOnly, middleware.NoStore, consistency.Track(pools.ReadYourWrites))
+
+	writes.HandleFunc("/{bookId}", func(w http.ResponseWriter, r *http.Request) {
+		controllers.AddFavorite(w, r, db)
+	}).Methods("POST")
+
+	writes.HandleFunc("/{bookId}", func(w http.ResponseWriter, r *http.Request) {
+		controllers.RemoveFavorite(w, r, db)
+	}).Methods("DELETE")
+}
@@ -0,0 +1,151 @@
+package auth
+
+import (
+	"context"
+	"crypto/subtle"
+	"fmt"
+	"golang-api-rest-swagger/Core/Shared/respond"
+	"net/http"
+	"strings"
+)
+
+// APIKeyHeader is the request header carrying the caller's API key.
+const APIKeyHeader = "X-API-Key"
+
+// Roles granted to authenticated callers.
+const (
+	RoleAdmin = "admin"
+	RoleUser  = "user"
+)
+
+// Principal identifies the caller of an authenticated request.
+type Principal struct {
+	Subject string `json:"subject"`
+	Role    string `json:"role"`
+}
+
+// Keys maps API keys to the principal they authenticate.
+type Keys map[string]Principal
+
+// ParseKeys parses a comma separated list of subject:key pairs, as found in the API_KEYS
+// environment variable, into user principals.
+func ParseKeys(s string) (Keys, error) {
+	keys := Keys{}
+	for _, pair := range strings.Split(s, ",") {
+		pair = strings.TrimSpace(pair)
+		if pair == "" {
+			continue
+		}
+		subject, key, ok := strings.Cut(pair, ":")
+		if !ok || subject == "" || key == "" {
+			return nil, fmt.Errorf("invalid API key entry %q, expected subject:key", pair)
+		}
+		keys[key] = Principal{Subject: subject, Role: RoleUser}
+	}
+	return keys, nil
+}
+
+// Lookup returns the principal authenticated by key. Every configured key is compared in
+// constant time so the lookup does not leak which keys exist.
+func (k Keys) Lookup(key string) (Principal, bool) {
+	var found Principal
+	ok := false
+	for candidate, p := range k {
+		if subtle.ConstantTimeCompare([]byte(key), []byte(candidate)) == 1 {
+			found, ok = p, true
+		}
+	}
+	return found, ok
+}
+
+type contextKey struct{}
+
+// WithPrincipal returns a copy of ctx carrying the given principal.
+func WithPrincipal(ctx context.Context, p Principal) context.Context {
+	return context.WithValue(ctx, contextKey{}, p)
+}
+
+// FromContext returns the principal stored in ctx, if any.
+func FromContext(ctx context.Context) (Principal, bool) {
+	p, ok := ctx.Value(contextKey{}).(Principal)
+	return p, ok
+}
+
+// Anonymous is the actor recorded for requests made without an API key.
+const Anonymous = "anonymous"
+
+// Actor returns the subject of the principal stored in ctx, or Anonymous when there is none.
+func Actor(ctx context.Context) string {
+	if p, ok := FromContext(ctx); ok {
+		return p.Subject
+	}
+	return Anonymous
+}
+
+// Identify stores the principal of requests presenting a valid API key in the request context.
+// Requests without a key pass through anonymously; requests with an unknown key are rejected.
+func Identify(keys Keys) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			key := r.Header.Get(APIKeyHeader)
+			if key == "" {
+				next.ServeHTTP(w, r)
+				return
+			}
+			p, ok := keys.Lookup(key)
+			if !ok {
+				respond.Error(w, "Unauthorized", http.StatusUnauthorized)
+				return
+			}
+			next.ServeHTTP(w, r.WithContext(WithPrincipal(r.Context(), p)))
+		})
+	}
+}
+
+// Require only lets requests through when they present one of the given API keys,
+// and stores the matching principal in the request context.
+func Require(keys Keys) func(http.Handler) http.Handler {
+	return requireRole(keys, "")
+}
+
+// RequireAdmin only lets requests through when they present an admin API key.
+func RequireAdmin(keys Keys) func(http.Handler) http.Handler {
+	return requireRole(keys, RoleAdmin)
+}
+
+func requireRole(keys Keys, role string) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			key := r.Header.Get(APIKeyHeader)
+			p, ok := keys.Lookup(key)
+			if key == "" || !ok {
+				respond.Error(w, "Unauthorized", http.StatusUnauthorized)
+				return
+			}
+			if role != "" && p.Role != role {
+				respond.Error(w, "Forbidden", http.StatusForbidden)
+				return
+			}
+			next.ServeHTTP(w, r.WithContext(WithPrincipal(r.Context(), p)))
+		})
+	}
+}
+
+// BasicAuth only lets requests through when they present the given HTTP Basic credentials,
+// and otherwise answers 401 with a WWW-Authenticate challenge so browsers prompt for them.
+func BasicAuth(user, password, realm string) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			u, p, ok := r.BasicAuth()
+			// Compare both values even when the first differs, so the response time leaks neither.
+			userOK := subtle.ConstantTimeCompare([]byte(u), []byte(user)) == 1
+			passwordOK := subtle.ConstantTimeCompare([]byte(p), []byte(password)) == 1
+			if !ok || !userOK || !passwordOK {
+				w.Header().Set("WWW-Authenticate", fmt.Sprintf(`Basic realm=%q, charset="UTF-8"`, realm))
+				respond.Error(w, "Unauthorized", http.StatusUnauthorized)
+				return
+			}
+			next.ServeHTTP(w, r)
+		})
+	}
+}
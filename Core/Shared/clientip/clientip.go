@@ -0,0 +1,80 @@
+package clientip
+
+import (
+	"context"
+	"net"
+	"net/http"
+	"net/netip"
+	"slices"
+	"strings"
+)
+
+type contextKey struct{}
+
+// FromContext returns the client IP of the request ctx belongs to, or "" outside of a request.
+func FromContext(ctx context.Context) string {
+	ip, _ := ctx.Value(contextKey{}).(string)
+	return ip
+}
+
+// Middleware stores the client IP of every request in its context, for FromContext. It is the
+// peer address unless the peer is one of the trusted proxies, in which case the forwarding
+// headers set by the proxies are believed; from anyone else they could be forged.
+func Middleware(trusted []netip.Prefix) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			ip := Resolve(r, trusted)
+			next.ServeHTTP(w, r.WithContext(context.WithValue(r.Context(), contextKey{}, ip)))
+		})
+	}
+}
+
+// Resolve returns the IP of the client that sent r. When the peer is a trusted proxy, the
+// X-Forwarded-For chain is walked from the right, each hop having been appended by the proxy in
+// front of it, and the first address that is not a trusted proxy is the client: addresses left of
+// it were written by the client itself. X-Real-IP is used when there is no X-Forwarded-For.
+func Resolve(r *http.Request, trusted []netip.Prefix) string {
+	peer := r.RemoteAddr
+	if host, _, err := net.SplitHostPort(peer); err == nil {
+		peer = host
+	}
+	addr, err := netip.ParseAddr(peer)
+	if err != nil || !isTrusted(addr, trusted) {
+		return peer
+	}
+
+	var hops []string
+	for _, header := range r.Header.Values("X-Forwarded-For") {
+		hops = append(hops, strings.Split(header, ",")...)
+	}
+	if len(hops) == 0 {
+		if real, err := netip.ParseAddr(strings.TrimSpace(r.Header.Get("X-Real-IP"))); err == nil {
+			return real.Unmap().String()
+		}
+		return peer
+	}
+	client := peer
+	for _, hop := range slices.Backward(hops) {
+		addr, err := netip.ParseAddr(strings.TrimSpace(hop))
+		if err != nil {
+			// Garbage cannot have come from a trusted proxy; trust nothing further left.
+			break
+		}
+		client = addr.Unmap().String()
+		if !isTrusted(addr, trusted) {
+			break
+		}
+	}
+	return client
+}
+
+// isTrusted reports whether addr is within one of the trusted proxy ranges.
+func isTrusted(addr netip.Addr, trusted []netip.Prefix) bool {
+	addr = addr.Unmap()
+	for _, prefix := range trusted {
+		if prefix.Contains(addr) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,539 @@
+package config
+
+import (
+	"fmt"
+	"github.com/joho/godotenv"
+	"log"
+	"net/netip"
+	"net/url"
+	"os"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Config holds the application settings read from the environment.
+type Config struct {
+	Port string
+	// ShutdownTimeout is how long in-flight requests may take to finish on shutdown.
+	ShutdownTimeout time.Duration
+	// RequestTimeout is the deadline of each request's database work, and the largest one a client may ask for.
+	RequestTimeout time.Duration
+	// RouteTimeouts replaces RequestTimeout on some routes, keyed by method and path template,
+	// e.g. "GET /books/export".
+	RouteTimeouts map[string]time.Duration
+	// MaxResponseBytes is the largest JSON response body. Zero disables the limit.
+	MaxResponseBytes int64
+	// MaxConcurrentRequests is the number of requests served at a time. Zero disables the limit.
+	MaxConcurrentRequests int
+	// TrustedProxies are the addresses of the reverse proxies whose X-Forwarded-For and X-Real-IP
+	// headers are believed. Empty, the client IP is always the peer address.
+	TrustedProxies []netip.Prefix
+	// CacheMaxAge is how long browsers and CDNs may cache the book reads. Zero leaves caching to
+	// each endpoint.
+	CacheMaxAge time.Duration
+	// HealthCheckInterval is how often the database is pinged to update the readiness probe.
+	HealthCheckInterval time.Duration
+	Database            Database
+	Listing             Listing
+	Swagger             Swagger
+	Bulk                Bulk
+	Import              Import
+	CORS                CORS
+	Validation          Validation
+	Purge               Purge
+	ReadOnly            bool
+	// PutUpsert makes PUT /books/{id} create the book when the id does not exist.
+	PutUpsert bool
+	// RequireJSONContentType rejects POST, PUT and PATCH bodies not sent as application/json, or
+	// application/merge-patch+json for PATCH, with 415.
+	RequireJSONContentType bool
+	AdminAPIKey            string
+	APIKeys                string
+	// Features switches the optional endpoints on and off.
+	Features Features
+	// LogLevel is info (default) or debug, which also logs request and response bodies.
+	LogLevel string
+	// Environment is production (default) or development. Debugging aids that could leak
+	// internals, like X-Debug-SQL, only work in development.
+	Environment string
+	// JSONNaming is the key style of JSON responses: snake (default) or camel.
+	JSONNaming string
+	// TimeFormat is how times are written in JSON responses: rfc3339 (default), unix or unixmilli.
+	TimeFormat string
+	// ResponseEnvelope is none (default), sending bodies as is, or jsend, wrapping them in a JSend envelope.
+	ResponseEnvelope string
+}
+
+// Database holds the MySQL connection and pool settings.
+type Database struct {
+	User     string
+	Password string
+	Name     string
+	Host     string
+	Port     string
+	// ReadHost is the host of the read replica serving the GET endpoints, empty when there is
+	// none. ReadPort, ReadUser and ReadPassword default to the primary's.
+	ReadHost     string
+	ReadPort     string
+	ReadUser     string
+	ReadPassword string
+	// ReadYourWrites is how long the reads of a client that wrote are served by the primary
+	// rather than the lagging replica. Zero always reads from the replica.
+	ReadYourWrites  time.Duration
+	MaxOpenConns    int
+	MaxIdleConns    int
+	ConnMaxLifetime time.Duration
+	TablePrefix     string
+	// UniqueTitleAuthor adds a unique key on the title and author of books, so no two books,
+	// soft deleted ones included, share both. Disabling it drops the key.
+	UniqueTitleAuthor bool
+	// RunMigrations applies the pending migrations at startup. Disabled, a separate step owns the
+	// schema and startup only checks it is up to date.
+	RunMigrations bool
+	// Params is the query string appended to the DSN: the defaults charset=utf8mb4&parseTime=true&loc=UTC
+	// with DB_PARAMS merged on top.
+	Params string
+}
+
+// Bulk holds the limits of the bulk endpoints.
+type Bulk struct {
+	// MaxItems is the largest number of items in a bulk request.
+	MaxItems int
+	// MaxBodyBytes is the largest size of a bulk request body.
+	MaxBodyBytes int64
+}
+
+// Import holds the limits of the imports from a URL.
+type Import struct {
+	// Timeout is how long the download of a file may take.
+	Timeout time.Duration
+	// MaxBytes is the largest size of a downloaded file.
+	MaxBytes int64
+}
+
+// Purge holds the settings of the background purge of soft deleted books.
+type Purge struct {
+	Enabled bool
+	// Interval is the time between two purges.
+	Interval time.Duration
+	// Retention is how long a soft deleted book is kept before it is permanently deleted.
+	Retention time.Duration
+}
+
+// Validation holds the configurable validation limits of books.
+type Validation struct {
+	// MaxTitleLength and MaxAuthorLength are the largest number of characters of a title and an
+	// author. They cannot exceed the size of their columns.
+	MaxTitleLength  int
+	MaxAuthorLength int
+}
+
+// CORS holds the cross-origin settings. CORS headers are only sent when AllowedOrigins is set.
+type CORS struct {
+	// AllowedOrigins are the origins allowed to call the API; "*" allows any origin.
+	AllowedOrigins []string
+	AllowedMethods []string
+	AllowedHeaders []string
+	// MaxAge is how long browsers may cache a preflight response. Zero omits the header.
+	MaxAge time.Duration
+	// AllowCredentials lets browsers send cookies and HTTP auth. It cannot be combined with "*".
+	AllowCredentials bool
+}
+
+// Swagger holds the settings of the Swagger UI.
+type Swagger struct {
+	Enabled bool
+	// Path is where the UI is mounted, always with a leading and a trailing slash.
+	Path string
+	// User and Password protect the UI with HTTP Basic Auth when set. Both or neither must be set.
+	User     string
+	Password string
+}
+
+// Listing holds the settings of the book list endpoint.
+type Listing struct {
+	// MaxUnpaginatedResults is the largest number of books returned without pagination.
+	// Zero disables the limit.
+	MaxUnpaginatedResults int
+	// MaxPageSize is the largest page size a client may request.
+	MaxPageSize int
+	// RejectOversizedPages makes requests above MaxPageSize fail with 400 instead of being clamped.
+	RejectOversizedPages bool
+	// StreamThreshold is the number of books above which a JSON list is streamed instead of
+	// buffered with a Content-Length. Zero always buffers.
+	StreamThreshold int
+	// EmptyNoContent makes a list without results answer 204 No Content instead of 200 with [].
+	EmptyNoContent bool
+	// DefaultSort is the order of lists requested without ?sort=, in the same syntax, e.g.
+	// -created_at. Empty sorts by id.
+	DefaultSort string
+	// SeekOffset is the offset from which pages of a list sorted by id are read by seeking to
+	// their first id instead of skipping the previous rows. Zero always uses OFFSET.
+	SeekOffset int
+}
+
+// tablePrefixPattern restricts TABLE_PREFIX to characters that are safe in an unquoted identifier.
+var tablePrefixPattern = regexp.MustCompile(`^[A-Za-z0-9_]*$`)
+
+// defaultDBParams are the DSN parameters DB_PARAMS is merged on top of. parseTime is required, as
+// timestamps are scanned into times.
+var defaultDBParams = url.Values{"charset": {"utf8mb4"}, "parseTime": {"true"}, "loc": {"UTC"}}
+
+// dbParams returns the DSN query string of the DB_PARAMS override s: its parameters replace the
+// defaults of the same name and add to the others, so tls=true keeps parseTime=true. Turning
+// parseTime off is refused, as every time scan would then fail at runtime.
+func dbParams(s string) (string, error) {
+	override, err := url.ParseQuery(s)
+	if err != nil {
+		return "", fmt.Errorf("invalid DB_PARAMS %q: %v", s, err)
+	}
+	params := url.Values{}
+	for name, values := range defaultDBParams {
+		params[name] = values
+	}
+	for name, values := range override {
+		params[name] = values
+	}
+	if parseTime := params.Get("parseTime"); parseTime != "true" {
+		return "", fmt.Errorf("invalid DB_PARAMS %q: parseTime must be true, got %q", s, parseTime)
+	}
+	return params.Encode(), nil
+}
+
+// Load reads the configuration from the environment, loading a .env file first when present.
+func Load() (Config, error) {
+	// Load environment variables from .env file
+	if err := godotenv.Load(); err != nil {
+		log.Println("Error loading .env file, using system env variables")
+	}
+
+	cfg := Config{
+		Port: getEnv("PORT", "8080"),
+		Database: Database{
+			User:            os.Getenv("MYSQL_USER"),
+			Password:        os.Getenv("MYSQL_PASSWORD"),
+			Name:            os.Getenv("MYSQL_DATABASE"),
+			Host:            os.Getenv("MYSQL_HOST"),
+			Port:            os.Getenv("MYSQL_PORT"),
+			ReadHost:        os.Getenv("MYSQL_READ_HOST"),
+			ReadPort:        getEnv("MYSQL_READ_PORT", os.Getenv("MYSQL_PORT")),
+			ReadUser:        getEnv("MYSQL_READ_USER", os.Getenv("MYSQL_USER")),
+			ReadPassword:    getEnv("MYSQL_READ_PASSWORD", os.Getenv("MYSQL_PASSWORD")),
+			MaxOpenConns:    10,
+			MaxIdleConns:    5,
+			ConnMaxLifetime: 0,
+			TablePrefix:     os.Getenv("TABLE_PREFIX"),
+		},
+		AdminAPIKey:      os.Getenv("ADMIN_API_KEY"),
+		APIKeys:          os.Getenv("API_KEYS"),
+		JSONNaming:       getEnv("JSON_NAMING", "snake"),
+		TimeFormat:       getEnv("TIME_FORMAT", "rfc3339"),
+		LogLevel:         getEnv("LOG_LEVEL", "info"),
+		Environment:      getEnv("APP_ENV", "production"),
+		ResponseEnvelope: getEnv("RESPONSE_ENVELOPE", "none"),
+	}
+
+	// Check if the database credentials are set.
+	db := cfg.Database
+	if db.User == "" || db.Password == "" || db.Name == "" || db.Host == "" || db.Port == "" {
+		return Config{}, fmt.Errorf("database credentials not set in .env or system environment")
+	}
+
+	if !tablePrefixPattern.MatchString(db.TablePrefix) {
+		return Config{}, fmt.Errorf("invalid TABLE_PREFIX %q: only letters, digits and underscores are allowed", db.TablePrefix)
+	}
+
+	if cfg.JSONNaming != "snake" && cfg.JSONNaming != "camel" {
+		return Config{}, fmt.Errorf("invalid JSON_NAMING %q: must be snake or camel", cfg.JSONNaming)
+	}
+
+	if cfg.TimeFormat != "rfc3339" && cfg.TimeFormat != "unix" && cfg.TimeFormat != "unixmilli" {
+		return Config{}, fmt.Errorf("invalid TIME_FORMAT %q: must be rfc3339, unix or unixmilli", cfg.TimeFormat)
+	}
+
+	if cfg.ResponseEnvelope != "none" && cfg.ResponseEnvelope != "jsend" {
+		return Config{}, fmt.Errorf("invalid RESPONSE_ENVELOPE %q: must be none or jsend", cfg.ResponseEnvelope)
+	}
+
+	if cfg.LogLevel != "info" && cfg.LogLevel != "debug" {
+		return Config{}, fmt.Errorf("invalid LOG_LEVEL %q: must be info or debug", cfg.LogLevel)
+	}
+
+	if cfg.Environment != "production" && cfg.Environment != "development" {
+		return Config{}, fmt.Errorf("invalid APP_ENV %q: must be production or development", cfg.Environment)
+	}
+
+	var err error
+	if cfg.Database.Params, err = dbParams(strings.TrimPrefix(os.Getenv("DB_PARAMS"), "?")); err != nil {
+		return Config{}, err
+	}
+	if cfg.Database.UniqueTitleAuthor, err = getBool("ENFORCE_UNIQUE_TITLE_AUTHOR", false); err != nil {
+		return Config{}, err
+	}
+	if cfg.Database.RunMigrations, err = getBool("RUN_MIGRATIONS", true); err != nil {
+		return Config{}, err
+	}
+	if cfg.ReadOnly, err = getBool("READ_ONLY", false); err != nil {
+		return Config{}, err
+	}
+	if cfg.Purge.Enabled, err = getBool("PURGE_ENABLED", false); err != nil {
+		return Config{}, err
+	}
+	purgeMinutes, err := getInt("PURGE_INTERVAL_MINUTES", 60)
+	if err != nil {
+		return Config{}, err
+	}
+	retentionDays, err := getInt("PURGE_RETENTION_DAYS", 30)
+	if err != nil {
+		return Config{}, err
+	}
+	if purgeMinutes == 0 || retentionDays == 0 {
+		return Config{}, fmt.Errorf("invalid PURGE_INTERVAL_MINUTES or PURGE_RETENTION_DAYS: must be at least 1")
+	}
+	cfg.Purge.Interval = time.Duration(purgeMinutes) * time.Minute
+	cfg.Purge.Retention = time.Duration(retentionDays) * 24 * time.Hour
+	if cfg.Validation.MaxTitleLength, err = getInt("MAX_TITLE_LEN", 255); err != nil {
+		return Config{}, err
+	}
+	if cfg.Validation.MaxAuthorLength, err = getInt("MAX_AUTHOR_LEN", 255); err != nil {
+		return Config{}, err
+	}
+	if cfg.Bulk.MaxItems, err = getInt("MAX_BULK_ITEMS", 1000); err != nil {
+		return Config{}, err
+	}
+	bulkBytes, err := getInt("MAX_BULK_BODY_BYTES", 1<<20)
+	if err != nil {
+		return Config{}, err
+	}
+	if cfg.Bulk.MaxItems == 0 || bulkBytes == 0 {
+		return Config{}, fmt.Errorf("invalid MAX_BULK_ITEMS or MAX_BULK_BODY_BYTES: must be at least 1")
+	}
+	cfg.Bulk.MaxBodyBytes = int64(bulkBytes)
+	importSeconds, err := getInt("IMPORT_URL_TIMEOUT_SECONDS", 30)
+	if err != nil {
+		return Config{}, err
+	}
+	importBytes, err := getInt("IMPORT_URL_MAX_BYTES", 10<<20)
+	if err != nil {
+		return Config{}, err
+	}
+	if importSeconds == 0 || importBytes == 0 {
+		return Config{}, fmt.Errorf("invalid IMPORT_URL_TIMEOUT_SECONDS or IMPORT_URL_MAX_BYTES: must be at least 1")
+	}
+	cfg.Import.Timeout = time.Duration(importSeconds) * time.Second
+	cfg.Import.MaxBytes = int64(importBytes)
+	if cfg.Swagger.Enabled, err = getBool("SWAGGER_ENABLED", true); err != nil {
+		return Config{}, err
+	}
+	cfg.Swagger.Path = "/" + strings.Trim(getEnv("SWAGGER_PATH", "/swagger/"), "/") + "/"
+	if cfg.Swagger.Path == "//" {
+		return Config{}, fmt.Errorf("invalid SWAGGER_PATH: the Swagger UI cannot be mounted at the root")
+	}
+	cfg.Swagger.User, cfg.Swagger.Password = os.Getenv("SWAGGER_USER"), os.Getenv("SWAGGER_PASS")
+	if (cfg.Swagger.User == "") != (cfg.Swagger.Password == "") {
+		return Config{}, fmt.Errorf("SWAGGER_USER and SWAGGER_PASS must be set together")
+	}
+	cfg.CORS.AllowedOrigins = getList("CORS_ALLOWED_ORIGINS", "")
+	cfg.CORS.AllowedMethods = getList("CORS_ALLOWED_METHODS", "GET,POST,PUT,PATCH,DELETE")
+	cfg.CORS.AllowedHeaders = getList("CORS_ALLOWED_HEADERS", "Content-Type,Prefer,X-API-Key,X-Request-ID,X-Request-Timeout-Ms")
+	maxResponseBytes, err := getInt("MAX_RESPONSE_BYTES", 0)
+	if err != nil {
+		return Config{}, err
+	}
+	if maxResponseBytes < 0 {
+		return Config{}, fmt.Errorf("invalid MAX_RESPONSE_BYTES: must be at least 0")
+	}
+	cfg.MaxResponseBytes = int64(maxResponseBytes)
+	readYourWrites, err := getInt("READ_YOUR_WRITES_SECONDS", 0)
+	if err != nil {
+		return Config{}, err
+	}
+	if readYourWrites < 0 {
+		return Config{}, fmt.Errorf("invalid READ_YOUR_WRITES_SECONDS: must be at least 0")
+	}
+	cfg.Database.ReadYourWrites = time.Duration(readYourWrites) * time.Second
+	cacheMaxAge, err := getInt("CACHE_MAX_AGE_SECONDS", 0)
+	if err != nil {
+		return Config{}, err
+	}
+	if cacheMaxAge < 0 {
+		return Config{}, fmt.Errorf("invalid CACHE_MAX_AGE_SECONDS: must be at least 0")
+	}
+	cfg.CacheMaxAge = time.Duration(cacheMaxAge) * time.Second
+	corsMaxAge, err := getInt("CORS_MAX_AGE_SECONDS", 600)
+	if err != nil {
+		return Config{}, err
+	}
+	cfg.CORS.MaxAge = time.Duration(corsMaxAge) * time.Second
+	if cfg.CORS.AllowCredentials, err = getBool("CORS_ALLOW_CREDENTIALS", false); err != nil {
+		return Config{}, err
+	}
+	// Browsers reject credentialed responses with a wildcard origin, so this could never work.
+	if cfg.CORS.AllowCredentials && slices.Contains(cfg.CORS.AllowedOrigins, "*") {
+		return Config{}, fmt.Errorf("CORS_ALLOW_CREDENTIALS cannot be used with CORS_ALLOWED_ORIGINS=*: list the allowed origins instead")
+	}
+	if cfg.Features, err = loadFeatures(); err != nil {
+		return Config{}, err
+	}
+	if cfg.PutUpsert, err = getBool("PUT_UPSERT", false); err != nil {
+		return Config{}, err
+	}
+	if cfg.RequireJSONContentType, err = getBool("REQUIRE_JSON_CONTENT_TYPE", true); err != nil {
+		return Config{}, err
+	}
+	shutdownSeconds, err := getInt("SHUTDOWN_TIMEOUT_SECONDS", 15)
+	if err != nil {
+		return Config{}, err
+	}
+	cfg.ShutdownTimeout = time.Duration(shutdownSeconds) * time.Second
+	requestSeconds, err := getInt("REQUEST_TIMEOUT_SECONDS", 30)
+	if err != nil {
+		return Config{}, err
+	}
+	if requestSeconds == 0 {
+		return Config{}, fmt.Errorf("invalid REQUEST_TIMEOUT_SECONDS: must be at least 1")
+	}
+	cfg.RequestTimeout = time.Duration(requestSeconds) * time.Second
+	if cfg.RouteTimeouts, err = parseRouteTimeouts(os.Getenv("ROUTE_TIMEOUTS")); err != nil {
+		return Config{}, err
+	}
+	if cfg.MaxConcurrentRequests, err = getInt("MAX_CONCURRENT_REQUESTS", 0); err != nil {
+		return Config{}, err
+	}
+	if cfg.TrustedProxies, err = parseTrustedProxies(getList("TRUSTED_PROXIES", "")); err != nil {
+		return Config{}, err
+	}
+	healthSeconds, err := getInt("HEALTH_CHECK_INTERVAL_SECONDS", 10)
+	if err != nil {
+		return Config{}, err
+	}
+	if healthSeconds == 0 {
+		return Config{}, fmt.Errorf("invalid HEALTH_CHECK_INTERVAL_SECONDS: must be at least 1")
+	}
+	cfg.HealthCheckInterval = time.Duration(healthSeconds) * time.Second
+	if cfg.Listing.MaxUnpaginatedResults, err = getInt("MAX_UNPAGINATED_RESULTS", 1000); err != nil {
+		return Config{}, err
+	}
+	if cfg.Listing.MaxPageSize, err = getInt("MAX_PAGE_SIZE", 100); err != nil {
+		return Config{}, err
+	}
+	if cfg.Listing.MaxPageSize == 0 {
+		return Config{}, fmt.Errorf("invalid MAX_PAGE_SIZE: must be at least 1")
+	}
+	switch policy := getEnv("PAGE_SIZE_POLICY", "clamp"); policy {
+	case "clamp":
+	case "reject":
+		cfg.Listing.RejectOversizedPages = true
+	default:
+		return Config{}, fmt.Errorf("invalid PAGE_SIZE_POLICY %q: must be clamp or reject", policy)
+	}
+	cfg.Listing.DefaultSort = os.Getenv("DEFAULT_SORT")
+	if cfg.Listing.StreamThreshold, err = getInt("STREAM_THRESHOLD", 500); err != nil {
+		return Config{}, err
+	}
+	if cfg.Listing.SeekOffset, err = getInt("SEEK_OFFSET_THRESHOLD", 10000); err != nil {
+		return Config{}, err
+	}
+	switch status := getEnv("EMPTY_LIST_STATUS", "200"); status {
+	case "200":
+	case "204":
+		cfg.Listing.EmptyNoContent = true
+	default:
+		return Config{}, fmt.Errorf("invalid EMPTY_LIST_STATUS %q: must be 200 or 204", status)
+	}
+
+	return cfg, nil
+}
+
+// parseRouteTimeouts parses ROUTE_TIMEOUTS, a comma separated list of "METHOD /path=duration"
+// items such as "GET /books/export=60s,GET /books/{id}=500ms".
+func parseRouteTimeouts(v string) (map[string]time.Duration, error) {
+	timeouts := map[string]time.Duration{}
+	for _, item := range strings.Split(v, ",") {
+		if item = strings.TrimSpace(item); item == "" {
+			continue
+		}
+		i := strings.LastIndex(item, "=")
+		if i < 0 {
+			return nil, fmt.Errorf("invalid ROUTE_TIMEOUTS item %q: must be METHOD /path=duration", item)
+		}
+		method, path, ok := strings.Cut(strings.TrimSpace(item[:i]), " ")
+		path = strings.TrimSpace(path)
+		if !ok || method == "" || !strings.HasPrefix(path, "/") {
+			return nil, fmt.Errorf("invalid ROUTE_TIMEOUTS item %q: must be METHOD /path=duration", item)
+		}
+		d, err := time.ParseDuration(strings.TrimSpace(item[i+1:]))
+		if err != nil || d <= 0 {
+			return nil, fmt.Errorf("invalid ROUTE_TIMEOUTS item %q: the timeout must be a positive duration such as 500ms or 60s", item)
+		}
+		timeouts[strings.ToUpper(method)+" "+path] = d
+	}
+	return timeouts, nil
+}
+
+// parseTrustedProxies parses TRUSTED_PROXIES, a list of IP addresses and CIDR ranges such as
+// "10.0.0.0/8,192.168.1.10".
+func parseTrustedProxies(items []string) ([]netip.Prefix, error) {
+	var prefixes []netip.Prefix
+	for _, item := range items {
+		prefix, err := netip.ParsePrefix(item)
+		if err != nil {
+			addr, addrErr := netip.ParseAddr(item)
+			if addrErr != nil {
+				return nil, fmt.Errorf("invalid TRUSTED_PROXIES item %q: must be an IP address or a CIDR range", item)
+			}
+			prefix = netip.PrefixFrom(addr.Unmap(), addr.Unmap().BitLen())
+		}
+		prefixes = append(prefixes, prefix.Masked())
+	}
+	return prefixes, nil
+}
+
+// getEnv returns the value of the environment variable key, or fallback when it is unset.
+func getEnv(key, fallback string) string {
+	if v := os.Getenv(key); v != "" {
+		return v
+	}
+	return fallback
+}
+
+// getList splits the comma separated environment variable key, or fallback when it is unset,
+// dropping blank items.
+func getList(key, fallback string) []string {
+	var items []string
+	for _, item := range strings.Split(getEnv(key, fallback), ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			items = append(items, item)
+		}
+	}
+	return items
+}
+
+// getBool parses the environment variable key as a boolean, or returns fallback when it is unset.
+func getBool(key string, fallback bool) (bool, error) {
+	v := os.Getenv(key)
+	if v == "" {
+		return fallback, nil
+	}
+	b, err := strconv.ParseBool(v)
+	if err != nil {
+		return false, fmt.Errorf("invalid %s %q: must be true or false", key, v)
+	}
+	return b, nil
+}
+
+// getInt parses the environment variable key as a non-negative integer, or returns fallback when it is unset.
+func getInt(key string, fallback int) (int, error) {
+	v := os.Getenv(key)
+	if v == "" {
+		return fallback, nil
+	}
+	n, err := strconv.Atoi(v)
+	if err != nil || n < 0 {
+		return 0, fmt.Errorf("invalid %s %q: must be a non-negative integer", key, v)
+	}
+	return n, nil
+}
@@ -0,0 +1,58 @@
+package config
+
+import (
+	"sort"
+	"strings"
+)
+
+// Names of the optional endpoints that can be switched with a FEATURE_<NAME> environment variable.
+const (
+	FeatureFavorites  = "favorites"
+	FeatureDuplicates = "duplicates"
+	FeatureExport     = "export"
+	FeatureReset      = "reset"
+	FeatureImport     = "import"
+)
+
+// featureDefaults lists every known feature with its state when its variable is unset.
+// Features that ship dark default to false.
+var featureDefaults = map[string]bool{
+	FeatureFavorites:  true,
+	FeatureDuplicates: true,
+	FeatureExport:     false,
+	FeatureReset:      false,
+	FeatureImport:     false,
+}
+
+// Features holds the state of the optional endpoints, keyed by feature name.
+type Features map[string]bool
+
+// Enabled reports whether the feature name is switched on. Unknown features are off.
+func (f Features) Enabled(name string) bool {
+	return f[name]
+}
+
+// String returns the enabled features as a sorted, comma-separated list.
+func (f Features) String() string {
+	var names []string
+	for name, on := range f {
+		if on {
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+	return strings.Join(names, ",")
+}
+
+// loadFeatures reads FEATURE_<NAME> for every known feature.
+func loadFeatures() (Features, error) {
+	features := make(Features, len(featureDefaults))
+	for name, fallback := range featureDefaults {
+		on, err := getBool("FEATURE_"+strings.ToUpper(name), fallback)
+		if err != nil {
+			return nil, err
+		}
+		features[name] = on
+	}
+	return features, nil
+}
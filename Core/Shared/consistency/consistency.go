@@ -0,0 +1,100 @@
+package consistency
+
+import (
+	"context"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+// Cookie is the cookie marking a client that wrote recently. It holds the time, in Unix
+// milliseconds, until which the client's reads are served by the primary.
+const Cookie = "read_your_writes"
+
+type contextKey struct{}
+
+// UsePrimary reports whether the reads of the request ctx belongs to must be served by the
+// primary, as the client wrote too recently for the replica to be sure to have caught up.
+func UsePrimary(ctx context.Context) bool {
+	primary, _ := ctx.Value(contextKey{}).(bool)
+	return primary
+}
+
+// Track sets the Cookie on the successful responses of the writes it is attached to, so the
+// client's reads go to the primary for window. Failed writes change nothing and set no cookie.
+// With a zero window the responses are left as is.
+func Track(window time.Duration) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		if window <= 0 {
+			return next
+		}
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			next.ServeHTTP(&trackWriter{ResponseWriter: w, window: window, secure: r.TLS != nil}, r)
+		})
+	}
+}
+
+// Route marks the reads it is attached to as served by the primary when the client presents an
+// unexpired Cookie. With a zero window, as for Track, every read stays on the replica.
+func Route(window time.Duration) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		if window <= 0 {
+			return next
+		}
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if wroteRecently(r) {
+				r = r.WithContext(context.WithValue(r.Context(), contextKey{}, true))
+			}
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
+// wroteRecently reports whether r carries a Cookie whose time has not passed yet. Browsers drop
+// the cookie once its Max-Age is over, other clients may send it back for longer.
+func wroteRecently(r *http.Request) bool {
+	c, err := r.Cookie(Cookie)
+	if err != nil {
+		return false
+	}
+	until, err := strconv.ParseInt(c.Value, 10, 64)
+	return err == nil && time.Now().UnixMilli() < until
+}
+
+// trackWriter sets the Cookie of a write once its status is known to be a success.
+type trackWriter struct {
+	http.ResponseWriter
+	window      time.Duration
+	secure      bool
+	wroteHeader bool
+}
+
+func (t *trackWriter) WriteHeader(status int) {
+	if !t.wroteHeader {
+		t.wroteHeader = true
+		if status < http.StatusBadRequest {
+			http.SetCookie(t.ResponseWriter, &http.Cookie{
+				Name:     Cookie,
+				Value:    strconv.FormatInt(time.Now().Add(t.window).UnixMilli(), 10),
+				Path:     "/",
+				MaxAge:   int((t.window + time.Second - 1) / time.Second),
+				Secure:   t.secure,
+				HttpOnly: true,
+				SameSite: http.SameSiteLaxMode,
+			})
+		}
+	}
+	t.ResponseWriter.WriteHeader(status)
+}
+
+func (t *trackWriter) Write(p []byte) (int, error) {
+	if !t.wroteHeader {
+		t.WriteHeader(http.StatusOK)
+	}
+	return t.ResponseWriter.Write(p)
+}
+
+// Unwrap lets http.ResponseController reach the underlying writer, so streaming handlers can still flush.
+func (t *trackWriter) Unwrap() http.ResponseWriter {
+	return t.ResponseWriter
+}
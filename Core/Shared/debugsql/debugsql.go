@@ -0,0 +1,67 @@
+package debugsql
+
+import (
+	"context"
+	"net/http"
+	"strconv"
+	"sync"
+)
+
+// Header is the request header asking for the executed SQL in the response.
+const Header = "X-Debug-SQL"
+
+// Query is an executed SQL statement with its arguments.
+type Query struct {
+	SQL  string `json:"sql"`
+	Args []any  `json:"args"`
+}
+
+// recorder collects the queries of one request.
+type recorder struct {
+	mu      sync.Mutex
+	queries []Query
+}
+
+type contextKey struct{}
+
+// Middleware lets requests sending X-Debug-SQL: true collect the SQL they execute, for the
+// response to include it. When enabled is false it returns next itself, so in production the
+// header has no effect whatever the request sends.
+func Middleware(enabled bool) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		if !enabled {
+			return next
+		}
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if on, _ := strconv.ParseBool(r.Header.Get(Header)); !on {
+				next.ServeHTTP(w, r)
+				return
+			}
+			next.ServeHTTP(w, r.WithContext(context.WithValue(r.Context(), contextKey{}, &recorder{})))
+		})
+	}
+}
+
+// Record adds a query to those collected for the request of ctx. It does nothing unless the
+// request asked for them.
+func Record(ctx context.Context, sql string, args ...any) {
+	rec, ok := ctx.Value(contextKey{}).(*recorder)
+	if !ok {
+		return
+	}
+	rec.mu.Lock()
+	defer rec.mu.Unlock()
+	rec.queries = append(rec.queries, Query{SQL: sql, Args: append([]any{}, args...)})
+}
+
+// Queries returns the queries collected for the request of ctx, and whether the request asked
+// for them.
+func Queries(ctx context.Context) ([]Query, bool) {
+	rec, ok := ctx.Value(contextKey{}).(*recorder)
+	if !ok {
+		return nil, false
+	}
+	rec.mu.Lock()
+	defer rec.mu.Unlock()
+	return append([]Query{}, rec.queries...), true
+}
@@ -0,0 +1,56 @@
+package health
+
+import (
+	"database/sql"
+	"fmt"
+	"golang-api-rest-swagger/Core/Shared/respond"
+	"net/http"
+	"time"
+)
+
+// Clocks is the body of GET /time. Its times are always RFC 3339 in UTC, whatever TIME_FORMAT, so
+// the diagnostic reads the same on every deployment.
+type Clocks struct {
+	// AppTime is the time of the server when the database answered, estimated as the middle of the
+	// query's round trip.
+	AppTime time.Time `json:"app_time" example:"2024-06-10T12:00:00.123456Z"`
+	DBTime  time.Time `json:"db_time" example:"2024-06-10T12:00:00.120001Z"`
+	// SkewMs is the database time minus the app time: positive when the database clock is ahead.
+	SkewMs float64 `json:"skew_ms" example:"-3.455"`
+	// RoundTripMs bounds the precision of the skew.
+	RoundTripMs float64 `json:"round_trip_ms" example:"0.84"`
+}
+
+// TimeHandler returns a handler comparing the clock of the database with that of the server, to
+// detect skew between them.
+// @Summary Compare the app and database clocks
+// @Description The current time of the server and of the database, as RFC 3339 in UTC whatever TIME_FORMAT, with the skew between them
+// @Tags health
+// @Produce json
+// @Success 200 {object} Clocks
+// @Failure 500 {string} string "Database query failed"
+// @Router /time [get]
+func TimeHandler(db *sql.DB) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		// UTC_TIMESTAMP rather than NOW(), which is in the session time zone, unknown to the driver.
+		var dbTime time.Time
+		start := time.Now()
+		err := db.QueryRowContext(r.Context(), "SELECT UTC_TIMESTAMP(6)").Scan(&dbTime)
+		roundTrip := time.Since(start)
+		if err != nil {
+			respond.Error(w, fmt.Sprintf("Database query failed: %v", err), http.StatusInternalServerError)
+			return
+		}
+		appTime := start.Add(roundTrip / 2)
+		// The value is UTC whatever location the driver read it in.
+		dbTime = time.Date(dbTime.Year(), dbTime.Month(), dbTime.Day(), dbTime.Hour(), dbTime.Minute(), dbTime.Second(), dbTime.Nanosecond(), time.UTC)
+
+		respond.JSON(w, r, http.StatusOK, Clocks{
+			AppTime:     appTime.UTC(),
+			DBTime:      dbTime,
+			SkewMs:      float64(dbTime.Sub(appTime).Microseconds()) / 1000,
+			RoundTripMs: float64(roundTrip.Microseconds()) / 1000,
+		})
+	}
+}
@@ -0,0 +1,103 @@
+package health
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"expvar"
+	"golang-api-rest-swagger/Core/Shared/metrics"
+	"golang-api-rest-swagger/Core/Shared/respond"
+	"log"
+	"net/http"
+	"sync/atomic"
+	"time"
+)
+
+// Metrics published on /metrics: db_up is 1 while the last ping succeeded, 0 otherwise, and
+// db_ping_failures counts the failed pings since startup.
+var (
+	dbUp           = expvar.NewInt("db_up")
+	dbPingFailures = expvar.NewInt("db_ping_failures")
+)
+
+func init() {
+	metrics.Describe("db_up", metrics.Description{Type: metrics.Gauge, Help: "1 while the last database ping succeeded, 0 otherwise."})
+	metrics.Describe("db_ping_failures", metrics.Description{Type: metrics.Counter, Help: "Failed database pings since startup."})
+}
+
+// Monitor pings the database in the background and remembers the outcome, so readiness
+// probes are answered without issuing a query of their own.
+type Monitor struct {
+	db       *sql.DB
+	interval time.Duration
+	ready    atomic.Bool
+}
+
+// Status is the body of GET /ready.
+type Status struct {
+	Status string `json:"status" example:"ready"`
+}
+
+// NewMonitor returns a monitor pinging db every interval. It reports not ready until the
+// first ping succeeds.
+func NewMonitor(db *sql.DB, interval time.Duration) *Monitor {
+	return &Monitor{db: db, interval: interval}
+}
+
+// Run pings the database right away, then every interval, until ctx is cancelled.
+func (m *Monitor) Run(ctx context.Context) {
+	ticker := time.NewTicker(m.interval)
+	defer ticker.Stop()
+	for {
+		m.check(ctx)
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// check pings the database once, bounded by the interval so a hanging connection does not
+// delay the next check. Every failure is logged, and so is the recovery.
+func (m *Monitor) check(ctx context.Context) {
+	ctx, cancel := context.WithTimeout(ctx, m.interval)
+	defer cancel()
+	err := m.db.PingContext(ctx)
+	if err != nil && errors.Is(ctx.Err(), context.Canceled) {
+		return // shutting down
+	}
+	if err != nil {
+		dbPingFailures.Add(1)
+		dbUp.Set(0)
+		m.ready.Store(false)
+		log.Printf("Database health check failed, marking not ready: %v", err)
+		return
+	}
+	dbUp.Set(1)
+	if !m.ready.Swap(true) {
+		log.Println("Database health check succeeded, marking ready")
+	}
+}
+
+// Ready reports whether the last ping succeeded.
+func (m *Monitor) Ready() bool {
+	return m.ready.Load()
+}
+
+// ReadyHandler godoc
+// @Summary Readiness probe
+// @Description Reports whether the last background database ping succeeded
+// @Tags health
+// @Produce json
+// @Success 200 {object} Status
+// @Failure 503 {object} Status
+// @Router /ready [get]
+func (m *Monitor) ReadyHandler(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	if !m.Ready() {
+		respond.Fail(w, r, http.StatusServiceUnavailable, Status{Status: "unavailable"})
+		return
+	}
+	respond.JSON(w, r, http.StatusOK, Status{Status: "ready"})
+}
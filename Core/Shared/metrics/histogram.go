@@ -0,0 +1,82 @@
+package metrics
+
+import (
+	"expvar"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// histogram counts observations in cumulative buckets, like a Prometheus histogram: each bucket
+// counts the observations less than or equal to its upper bound. It is an expvar.Var, shown on
+// /debug/vars as {"buckets":{"10":2,"100":5,"+Inf":6},"count":6,"sum":420}.
+type histogram struct {
+	bounds []float64
+	mu     sync.Mutex
+	counts []uint64
+	count  uint64
+	sum    float64
+}
+
+// newHistogram returns a histogram with the given ascending bucket upper bounds. A +Inf bucket
+// holding every observation is always added.
+func newHistogram(bounds ...float64) *histogram {
+	return &histogram{bounds: bounds, counts: make([]uint64, len(bounds))}
+}
+
+// Observe adds v to the histogram.
+func (h *histogram) Observe(v float64) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	for i, bound := range h.bounds {
+		if v <= bound {
+			h.counts[i]++
+		}
+	}
+	h.count++
+	h.sum += v
+}
+
+// String returns the histogram as JSON, for expvar.
+func (h *histogram) String() string {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	var b strings.Builder
+	b.WriteString(`{"buckets":{`)
+	for i, bound := range h.bounds {
+		b.WriteString(`"` + strconv.FormatFloat(bound, 'f', -1, 64) + `":` + strconv.FormatUint(h.counts[i], 10) + ",")
+	}
+	b.WriteString(`"+Inf":` + strconv.FormatUint(h.count, 10))
+	b.WriteString(`},"count":` + strconv.FormatUint(h.count, 10))
+	b.WriteString(`,"sum":` + strconv.FormatFloat(h.sum, 'f', -1, 64) + "}")
+	return b.String()
+}
+
+// HistogramMap is a set of histograms sharing their buckets, one per key, such as an endpoint.
+type HistogramMap struct {
+	vars   *expvar.Map
+	bounds []float64
+	mu     sync.Mutex
+}
+
+// NewHistogramMap publishes a map of histograms with the given bucket upper bounds under name,
+// described by d for the Prometheus exposition.
+func NewHistogramMap(name string, d Description, bounds ...float64) *HistogramMap {
+	d.Type = Histogram
+	Describe(name, d)
+	return &HistogramMap{vars: expvar.NewMap(name), bounds: bounds}
+}
+
+// Observe adds v to the histogram of key, creating it on first use.
+func (m *HistogramMap) Observe(key string, v float64) {
+	h, ok := m.vars.Get(key).(*histogram)
+	if !ok {
+		m.mu.Lock()
+		if h, ok = m.vars.Get(key).(*histogram); !ok {
+			h = newHistogram(m.bounds...)
+			m.vars.Set(key, h)
+		}
+		m.mu.Unlock()
+	}
+	h.Observe(v)
+}
@@ -0,0 +1,113 @@
+package metrics
+
+import (
+	"bufio"
+	"expvar"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// Metric types of the Prometheus text exposition format.
+const (
+	Counter   = "counter"
+	Gauge     = "gauge"
+	Histogram = "histogram"
+)
+
+// Description tells the Prometheus exposition what an expvar holds.
+type Description struct {
+	// Type is Counter, Gauge or, set by NewHistogramMap, Histogram.
+	Type string
+	Help string
+	// Label names the label holding the keys of an expvar.Map or a HistogramMap, e.g. endpoint.
+	Label string
+}
+
+var (
+	descriptionsMu sync.Mutex
+	descriptions   = map[string]Description{}
+)
+
+// Describe sets the description of the expvar published under name. Vars without one are exposed
+// as untyped, with their map keys under the key label.
+func Describe(name string, d Description) {
+	descriptionsMu.Lock()
+	defer descriptionsMu.Unlock()
+	descriptions[name] = d
+}
+
+func describe(name string) Description {
+	descriptionsMu.Lock()
+	defer descriptionsMu.Unlock()
+	d := descriptions[name]
+	if d.Label == "" {
+		d.Label = "key"
+	}
+	return d
+}
+
+// Handler serves the published integers, floats, maps of them and histogram maps in the
+// Prometheus text exposition format, version 0.0.4. Other expvars, like memstats, are left to
+// the JSON of expvar.Handler.
+func Handler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/plain; version=0.0.4; charset=utf-8")
+		b := bufio.NewWriter(w)
+		expvar.Do(func(kv expvar.KeyValue) {
+			writeVar(b, kv.Key, kv.Value)
+		})
+		b.Flush()
+	})
+}
+
+// writeVar writes v, published under name, unless it is of a kind the exposition does not cover.
+func writeVar(w io.Writer, name string, v expvar.Var) {
+	d := describe(name)
+	switch v := v.(type) {
+	case *expvar.Int, *expvar.Float:
+		writeHeader(w, name, d.Type, d.Help)
+		fmt.Fprintf(w, "%s %s\n", name, v.String())
+	case *expvar.Map:
+		writeHeader(w, name, d.Type, d.Help)
+		v.Do(func(kv expvar.KeyValue) {
+			labels := d.Label + `="` + escapeLabel(kv.Key) + `"`
+			switch value := kv.Value.(type) {
+			case *histogram:
+				value.writeTo(w, name, labels)
+			case *expvar.Int, *expvar.Float:
+				fmt.Fprintf(w, "%s{%s} %s\n", name, labels, value.String())
+			}
+		})
+	}
+}
+
+func writeHeader(w io.Writer, name, kind, help string) {
+	if help != "" {
+		fmt.Fprintf(w, "# HELP %s %s\n", name, strings.NewReplacer(`\`, `\\`, "\n", `\n`).Replace(help))
+	}
+	if kind == "" {
+		kind = "untyped"
+	}
+	fmt.Fprintf(w, "# TYPE %s %s\n", name, kind)
+}
+
+// escapeLabel escapes a label value for the exposition format.
+func escapeLabel(value string) string {
+	return strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`).Replace(value)
+}
+
+// writeTo writes the bucket, sum and count series of the histogram with the given labels.
+func (h *histogram) writeTo(w io.Writer, name, labels string) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	for i, bound := range h.bounds {
+		fmt.Fprintf(w, "%s_bucket{%s,le=\"%s\"} %d\n", name, labels, strconv.FormatFloat(bound, 'g', -1, 64), h.counts[i])
+	}
+	fmt.Fprintf(w, "%s_bucket{%s,le=\"+Inf\"} %d\n", name, labels, h.count)
+	fmt.Fprintf(w, "%s_sum{%s} %s\n", name, labels, strconv.FormatFloat(h.sum, 'g', -1, 64))
+	fmt.Fprintf(w, "%s_count{%s} %d\n", name, labels, h.count)
+}
@@ -0,0 +1,50 @@
+package metrics
+
+import (
+	"expvar"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestHandler(t *testing.T) {
+	up := expvar.NewInt("test_up")
+	up.Set(1)
+	Describe("test_up", Description{Type: Gauge, Help: "1 when up."})
+	failures := expvar.NewMap("test_failures")
+	failures.Add(`Create."quoted"`, 3)
+	Describe("test_failures", Description{Type: Counter, Label: "field"})
+	expvar.NewInt("test_untyped").Set(7)
+	sizes := NewHistogramMap("test_sizes", Description{Help: "Sizes.", Label: "endpoint"}, 10, 100)
+	for _, v := range []float64{5, 10, 50, 500} {
+		sizes.Observe("GET /books", v)
+	}
+
+	rec := httptest.NewRecorder()
+	Handler().ServeHTTP(rec, httptest.NewRequest("GET", "/metrics", nil))
+	if got := rec.Header().Get("Content-Type"); !strings.HasPrefix(got, "text/plain; version=0.0.4") {
+		t.Errorf("Content-Type = %q, want the text exposition format", got)
+	}
+	body := rec.Body.String()
+	for _, want := range []string{
+		"# HELP test_up 1 when up.\n# TYPE test_up gauge\ntest_up 1\n",
+		"# TYPE test_failures counter\ntest_failures{field=\"Create.\\\"quoted\\\"\"} 3\n",
+		"# TYPE test_untyped untyped\ntest_untyped 7\n",
+		"# HELP test_sizes Sizes.\n# TYPE test_sizes histogram\n" +
+			"test_sizes_bucket{endpoint=\"GET /books\",le=\"10\"} 2\n" +
+			"test_sizes_bucket{endpoint=\"GET /books\",le=\"100\"} 3\n" +
+			"test_sizes_bucket{endpoint=\"GET /books\",le=\"+Inf\"} 4\n" +
+			"test_sizes_sum{endpoint=\"GET /books\"} 565\n" +
+			"test_sizes_count{endpoint=\"GET /books\"} 4\n",
+	} {
+		if !strings.Contains(body, want) {
+			t.Errorf("exposition lacks\n%s\ngot\n%s", want, body)
+		}
+	}
+	// The Go runtime vars of expvar are not numbers or maps of them.
+	for _, name := range []string{"memstats", "cmdline"} {
+		if strings.Contains(body, name) {
+			t.Errorf("exposition has %s, which only /debug/vars serves", name)
+		}
+	}
+}
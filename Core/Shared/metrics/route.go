@@ -0,0 +1,20 @@
+package metrics
+
+import (
+	"github.com/gorilla/mux"
+	"net/http"
+)
+
+// RouteKey returns the method and path template of the route matched by r, e.g. "GET /books/{id}",
+// or an empty string when no route matched. Unlike the path, it takes a bounded number of values.
+func RouteKey(r *http.Request) string {
+	route := mux.CurrentRoute(r)
+	if route == nil {
+		return ""
+	}
+	template, err := route.GetPathTemplate()
+	if err != nil {
+		return ""
+	}
+	return r.Method + " " + template
+}
@@ -0,0 +1,86 @@
+package middleware
+
+import (
+	"bytes"
+	"golang-api-rest-swagger/Core/Shared/clientip"
+	"golang-api-rest-swagger/Core/Shared/requestid"
+	"golang-api-rest-swagger/Core/Shared/respond"
+	"io"
+	"log"
+	"net/http"
+	"strings"
+)
+
+// maxLoggedBody is the number of bytes of each body kept in the debug log.
+const maxLoggedBody = 2048
+
+// sensitiveHeaders are logged as [redacted] so credentials never reach the log.
+var sensitiveHeaders = []string{"X-Api-Key", "Authorization", "Cookie", "Set-Cookie"}
+
+// LogBodies logs every request and response with its headers and body, truncated to
+// maxLoggedBody bytes. Bodies may hold personal data, so it is only installed when LOG_LEVEL=debug.
+func LogBodies(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var body []byte
+		if r.Body != nil {
+			var err error
+			if body, err = io.ReadAll(r.Body); err != nil {
+				respond.Error(w, "Failed to read request body", http.StatusBadRequest)
+				return
+			}
+			r.Body.Close()
+			// Hand the handler a fresh reader over the same bytes.
+			r.Body = io.NopCloser(bytes.NewReader(body))
+		}
+		id := requestid.FromContext(r.Context())
+		log.Printf("debug request request_id=%s client_ip=%s %s %s headers=%v body=%s", id, clientip.FromContext(r.Context()), r.Method, r.URL.RequestURI(), redactHeaders(r.Header), truncate(body))
+
+		rec := &bodyRecorder{ResponseWriter: w, status: http.StatusOK}
+		next.ServeHTTP(rec, r)
+		log.Printf("debug response request_id=%s %s %s status=%d headers=%v body=%s", id, r.Method, r.URL.RequestURI(), rec.status, redactHeaders(w.Header()), truncate(rec.body.Bytes()))
+	})
+}
+
+// bodyRecorder passes the response through while keeping the status and the start of the body.
+type bodyRecorder struct {
+	http.ResponseWriter
+	status int
+	body   bytes.Buffer
+}
+
+func (b *bodyRecorder) WriteHeader(status int) {
+	b.status = status
+	b.ResponseWriter.WriteHeader(status)
+}
+
+func (b *bodyRecorder) Write(p []byte) (int, error) {
+	// Keep one byte more than is logged so truncate can tell the body was cut.
+	if room := maxLoggedBody + 1 - b.body.Len(); room > 0 {
+		b.body.Write(p[:min(room, len(p))])
+	}
+	return b.ResponseWriter.Write(p)
+}
+
+// Unwrap lets http.ResponseController reach the underlying writer, so streaming handlers can still flush.
+func (b *bodyRecorder) Unwrap() http.ResponseWriter {
+	return b.ResponseWriter
+}
+
+// redactHeaders returns a copy of h with the values of sensitive headers hidden.
+func redactHeaders(h http.Header) http.Header {
+	out := h.Clone()
+	for _, name := range sensitiveHeaders {
+		if out.Get(name) != "" {
+			out.Set(name, "[redacted]")
+		}
+	}
+	return out
+}
+
+// truncate returns body as a string of at most maxLoggedBody bytes, marking it when it was cut.
+func truncate(body []byte) string {
+	if len(body) <= maxLoggedBody {
+		return string(body)
+	}
+	return strings.ToValidUTF8(string(body[:maxLoggedBody]), "") + "...(truncated)"
+}
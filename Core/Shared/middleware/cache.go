@@ -0,0 +1,90 @@
+package middleware
+
+import (
+	"fmt"
+	"golang-api-rest-swagger/Core/Shared/auth"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// cacheVary are the request headers every cacheable response varies on: the negotiated format and
+// the content coding.
+var cacheVary = []string{"Accept", "Accept-Encoding"}
+
+// CacheControl lets browsers and CDNs cache the successful responses of the reads it is attached
+// to for maxAge. Responses to requests with an API key may hold fields hidden from anonymous
+// callers, so only the caller's own cache may keep them. Errors are never stored. A Cache-Control
+// set by the handler is kept, except no-cache, which maxAge relaxes: caches still revalidate with
+// the ETag once maxAge has passed. With a zero maxAge the responses are left as is.
+func CacheControl(maxAge time.Duration) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		if maxAge <= 0 {
+			return next
+		}
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			scope := "public"
+			if r.Header.Get(auth.APIKeyHeader) != "" {
+				scope = "private"
+			}
+			next.ServeHTTP(&cacheWriter{ResponseWriter: w, policy: fmt.Sprintf("%s, max-age=%d", scope, int(maxAge.Seconds()))}, r)
+		})
+	}
+}
+
+// NoStore keeps the responses of the writes it is attached to out of every cache.
+func NoStore(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Cache-Control", "no-store")
+		next.ServeHTTP(w, r)
+	})
+}
+
+// cacheWriter sets the Cache-Control and Vary headers of a response once its status is known.
+type cacheWriter struct {
+	http.ResponseWriter
+	policy      string
+	wroteHeader bool
+}
+
+func (c *cacheWriter) WriteHeader(status int) {
+	if !c.wroteHeader {
+		c.wroteHeader = true
+		h := c.Header()
+		switch current := h.Get("Cache-Control"); {
+		case status >= http.StatusBadRequest:
+			h.Set("Cache-Control", "no-store")
+		case current == "" || current == "no-cache":
+			h.Set("Cache-Control", c.policy)
+			addVary(h, cacheVary...)
+		}
+	}
+	c.ResponseWriter.WriteHeader(status)
+}
+
+func (c *cacheWriter) Write(p []byte) (int, error) {
+	if !c.wroteHeader {
+		c.WriteHeader(http.StatusOK)
+	}
+	return c.ResponseWriter.Write(p)
+}
+
+// Unwrap lets http.ResponseController reach the underlying writer, so streaming handlers can still flush.
+func (c *cacheWriter) Unwrap() http.ResponseWriter {
+	return c.ResponseWriter
+}
+
+// addVary adds the headers to the Vary of h that it does not list yet.
+func addVary(h http.Header, headers ...string) {
+	for _, header := range headers {
+		listed := false
+		for _, value := range h.Values("Vary") {
+			for _, name := range strings.Split(value, ",") {
+				listed = listed || strings.EqualFold(strings.TrimSpace(name), header)
+			}
+		}
+		if !listed {
+			h.Add("Vary", header)
+		}
+	}
+}
@@ -0,0 +1,64 @@
+package middleware
+
+import (
+	"golang-api-rest-swagger/Core/Shared/respond"
+	"mime"
+	"net/http"
+	"strings"
+)
+
+// jsonMediaType is the media type accepted for request bodies.
+const jsonMediaType = "application/json"
+
+// mergePatchMediaType is the media type of a JSON Merge Patch (RFC 7386), also accepted for PATCH
+// bodies: its absent members leave a field unchanged and its nulls clear it, as plain JSON patches do.
+const mergePatchMediaType = "application/merge-patch+json"
+
+// acceptPatch is the Accept-Patch header listing the media types accepted for PATCH bodies.
+const acceptPatch = jsonMediaType + ", " + mergePatchMediaType
+
+// uploadPaths are the paths taking a file upload instead of a JSON body, registered with AllowUpload.
+var uploadPaths = map[string]bool{}
+
+// AllowUpload exempts POST requests to path from RequireJSON, for an endpoint taking a file as
+// multipart/form-data, which checks its content type itself. It is meant to be called while the
+// routes are set up, before serving.
+func AllowUpload(path string) {
+	uploadPaths[path] = true
+}
+
+// RequireJSON rejects POST, PUT and PATCH requests whose body is not declared as JSON with 415
+// Unsupported Media Type, so a form or text body sent by mistake is not decoded as JSON anyway.
+// PATCH bodies may also be declared as a JSON Merge Patch. A charset parameter is allowed as long
+// as it is UTF-8. Requests without a body, like the ones adding a favorite, and uploads to the
+// paths of AllowUpload are left alone.
+func RequireJSON(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.Method {
+		case http.MethodPost, http.MethodPut, http.MethodPatch:
+		default:
+			next.ServeHTTP(w, r)
+			return
+		}
+		// Content-Length is -1 when the length is unknown, e.g. for a chunked body.
+		if r.ContentLength == 0 || r.Method == http.MethodPost && uploadPaths[strings.TrimSuffix(r.URL.Path, "/")] {
+			next.ServeHTTP(w, r)
+			return
+		}
+		mediaType, params, err := mime.ParseMediaType(r.Header.Get("Content-Type"))
+		if r.Method == http.MethodPatch && (err != nil || mediaType != jsonMediaType && mediaType != mergePatchMediaType) {
+			w.Header().Set("Accept-Patch", acceptPatch)
+			respond.Error(w, "Unsupported Content-Type: PATCH bodies must be sent as "+jsonMediaType+" or "+mergePatchMediaType, http.StatusUnsupportedMediaType)
+			return
+		}
+		if r.Method != http.MethodPatch && (err != nil || mediaType != jsonMediaType) {
+			respond.Error(w, "Unsupported Content-Type: request bodies must be sent as "+jsonMediaType, http.StatusUnsupportedMediaType)
+			return
+		}
+		if charset, ok := params["charset"]; ok && !strings.EqualFold(charset, "utf-8") {
+			respond.Error(w, "Unsupported charset "+charset+": request bodies must be UTF-8", http.StatusUnsupportedMediaType)
+			return
+		}
+		next.ServeHTTP(w, r)
+	})
+}
@@ -0,0 +1,56 @@
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestRequireJSON(t *testing.T) {
+	AllowUpload("/uploads")
+	defer delete(uploadPaths, "/uploads")
+	next := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusNoContent)
+	})
+	tests := []struct {
+		method      string
+		path        string
+		contentType string
+		body        string
+		want        int
+	}{
+		{"POST", "/books", "application/json", `{}`, http.StatusNoContent},
+		{"POST", "/books", "application/json; charset=utf-8", `{}`, http.StatusNoContent},
+		{"POST", "/books", "application/json; charset=UTF-8", `{}`, http.StatusNoContent},
+		{"PUT", "/books/1", "application/json", `{}`, http.StatusNoContent},
+		{"PATCH", "/books/1", "application/merge-patch+json", `{}`, http.StatusNoContent},
+		{"POST", "/books", "text/plain", `{}`, http.StatusUnsupportedMediaType},
+		{"POST", "/books", "application/x-www-form-urlencoded", "title=Dune", http.StatusUnsupportedMediaType},
+		{"POST", "/books", "", `{}`, http.StatusUnsupportedMediaType},
+		{"POST", "/books", "application/json; charset=latin1", `{}`, http.StatusUnsupportedMediaType},
+		{"PUT", "/books/1", "application/merge-patch+json", `{}`, http.StatusUnsupportedMediaType},
+		{"PATCH", "/books/1", "text/plain", `{}`, http.StatusUnsupportedMediaType},
+		// Bodiless writes, reads and uploads are left alone.
+		{"POST", "/favorites/1", "", "", http.StatusNoContent},
+		{"GET", "/books", "text/plain", "", http.StatusNoContent},
+		{"DELETE", "/books/1", "text/plain", `{}`, http.StatusNoContent},
+		{"POST", "/uploads", "multipart/form-data; boundary=x", "--x--", http.StatusNoContent},
+		{"POST", "/uploads/", "multipart/form-data; boundary=x", "--x--", http.StatusNoContent},
+		{"PUT", "/uploads", "multipart/form-data; boundary=x", "--x--", http.StatusUnsupportedMediaType},
+	}
+	for _, tt := range tests {
+		req := httptest.NewRequest(tt.method, tt.path, strings.NewReader(tt.body))
+		if tt.contentType != "" {
+			req.Header.Set("Content-Type", tt.contentType)
+		}
+		rec := httptest.NewRecorder()
+		RequireJSON(next).ServeHTTP(rec, req)
+		if rec.Code != tt.want {
+			t.Errorf("%s %s with Content-Type %q = %d, want %d: %s", tt.method, tt.path, tt.contentType, rec.Code, tt.want, rec.Body)
+		}
+		if tt.method == "PATCH" && rec.Code == http.StatusUnsupportedMediaType && rec.Header().Get("Accept-Patch") != acceptPatch {
+			t.Errorf("PATCH %s with Content-Type %q has Accept-Patch %q, want %q", tt.path, tt.contentType, rec.Header().Get("Accept-Patch"), acceptPatch)
+		}
+	}
+}
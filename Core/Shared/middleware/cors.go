@@ -0,0 +1,58 @@
+package middleware
+
+import (
+	"golang-api-rest-swagger/Core/Shared/config"
+	"net/http"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+// corsExposedHeaders are the response headers browsers let cross-origin scripts read.
+const corsExposedHeaders = "Link, Location, X-Page-Limit, X-Request-ID, X-Total-Count"
+
+// CORS adds the cross-origin headers for requests from the allowed origins and answers their
+// preflight requests itself. Requests from other origins are served without CORS headers, so
+// browsers block them. With a wildcard origin every origin is allowed, without credentials.
+func CORS(cfg config.CORS) func(http.Handler) http.Handler {
+	wildcard := slices.Contains(cfg.AllowedOrigins, "*")
+	methods := strings.Join(cfg.AllowedMethods, ", ")
+	headers := strings.Join(cfg.AllowedHeaders, ", ")
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			origin := r.Header.Get("Origin")
+			h := w.Header()
+			if !wildcard {
+				// The answer depends on the origin, so caches must not share it across origins.
+				h.Add("Vary", "Origin")
+			}
+			if origin == "" || (!wildcard && !slices.Contains(cfg.AllowedOrigins, origin)) {
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			if wildcard {
+				h.Set("Access-Control-Allow-Origin", "*")
+			} else {
+				h.Set("Access-Control-Allow-Origin", origin)
+			}
+			if cfg.AllowCredentials {
+				h.Set("Access-Control-Allow-Credentials", "true")
+			}
+
+			if r.Method != http.MethodOptions || r.Header.Get("Access-Control-Request-Method") == "" {
+				h.Set("Access-Control-Expose-Headers", corsExposedHeaders)
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			// Preflight: answered here, the request never reaches the router.
+			h.Set("Access-Control-Allow-Methods", methods)
+			h.Set("Access-Control-Allow-Headers", headers)
+			if cfg.MaxAge > 0 {
+				h.Set("Access-Control-Max-Age", strconv.Itoa(int(cfg.MaxAge.Seconds())))
+			}
+			w.WriteHeader(http.StatusNoContent)
+		})
+	}
+}
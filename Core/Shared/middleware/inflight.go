@@ -0,0 +1,24 @@
+package middleware
+
+import (
+	"net/http"
+	"sync/atomic"
+)
+
+// inFlight counts the requests currently being served.
+var inFlight atomic.Int64
+
+// InFlightRequests returns the number of requests currently being served.
+func InFlightRequests() int64 {
+	return inFlight.Load()
+}
+
+// TrackInFlight counts the requests being served by next. Wrap the whole router with it so
+// every request is counted, including the ones that match no route.
+func TrackInFlight(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		inFlight.Add(1)
+		defer inFlight.Add(-1)
+		next.ServeHTTP(w, r)
+	})
+}
@@ -0,0 +1,24 @@
+package middleware
+
+import (
+	"golang-api-rest-swagger/Core/Shared/respond"
+	"net/http"
+)
+
+// LimitConcurrency serves at most max requests at a time. Requests arriving while max requests
+// are being served are rejected at once with 503 instead of queueing for a database connection.
+func LimitConcurrency(max int) func(http.Handler) http.Handler {
+	slots := make(chan struct{}, max)
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			select {
+			case slots <- struct{}{}:
+				defer func() { <-slots }()
+				next.ServeHTTP(w, r)
+			default:
+				w.Header().Set("Retry-After", "1")
+				respond.Error(w, "Too many concurrent requests", http.StatusServiceUnavailable)
+			}
+		})
+	}
+}
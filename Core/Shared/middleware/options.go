@@ -0,0 +1,45 @@
+package middleware
+
+import (
+	"github.com/gorilla/mux"
+	"net/http"
+	"strings"
+)
+
+// probedMethods are the methods checked against the router when answering OPTIONS.
+var probedMethods = []string{"GET", "POST", "PUT", "PATCH", "DELETE"}
+
+// Options answers OPTIONS requests with 204 No Content and an Allow header listing the
+// methods the router accepts for the requested path, and an Accept-Patch header when PATCH is one
+// of them. It must be registered as the last route, after every other route is known.
+func Options(router *mux.Router) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		allowed := allowedMethods(router, r)
+		if len(allowed) == 0 {
+			http.NotFound(w, r)
+			return
+		}
+
+		w.Header().Set("Allow", strings.Join(append(allowed, "OPTIONS"), ", "))
+		for _, method := range allowed {
+			if method == http.MethodPatch {
+				w.Header().Set("Accept-Patch", acceptPatch)
+			}
+		}
+		w.WriteHeader(http.StatusNoContent)
+	}
+}
+
+// allowedMethods returns the probed methods the router has a route for at the path of r.
+func allowedMethods(router *mux.Router, r *http.Request) []string {
+	allowed := []string{}
+	for _, method := range probedMethods {
+		probe := r.Clone(r.Context())
+		probe.Method = method
+		var match mux.RouteMatch
+		if router.Match(probe, &match) && match.MatchErr == nil {
+			allowed = append(allowed, method)
+		}
+	}
+	return allowed
+}
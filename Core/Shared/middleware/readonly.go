@@ -0,0 +1,33 @@
+package middleware
+
+import (
+	"golang-api-rest-swagger/Core/Shared/respond"
+	"net/http"
+	"sync/atomic"
+)
+
+// readOnly holds the current read-only state. It is an atomic so it can be
+// toggled at runtime while requests are being served.
+var readOnly atomic.Bool
+
+// SetReadOnly enables or disables read-only mode.
+func SetReadOnly(enabled bool) {
+	readOnly.Store(enabled)
+}
+
+// IsReadOnly reports whether read-only mode is enabled.
+func IsReadOnly() bool {
+	return readOnly.Load()
+}
+
+// ReadOnly rejects every request with 503 Service Unavailable while read-only mode is enabled.
+// It is meant to be attached to the subrouter holding the mutating routes.
+func ReadOnly(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if IsReadOnly() {
+			respond.Error(w, "Service in read-only mode", http.StatusServiceUnavailable)
+			return
+		}
+		next.ServeHTTP(w, r)
+	})
+}
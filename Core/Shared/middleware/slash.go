@@ -0,0 +1,25 @@
+package middleware
+
+import (
+	"github.com/gorilla/mux"
+	"net/http"
+	"strings"
+)
+
+// TrailingSlash serves a request whose path ends with a slash, e.g. /books/, by the route of the
+// same path without it when only that one exists. The request is matched directly rather than
+// redirected, so clients need no extra round trip and request bodies are kept. Paths with a route
+// of their own, such as the Swagger UI, are left unchanged.
+func TrailingSlash(router *mux.Router) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/" && strings.HasSuffix(r.URL.Path, "/") && len(allowedMethods(router, r)) == 0 {
+			trimmed := r.Clone(r.Context())
+			trimmed.URL.Path = strings.TrimRight(r.URL.Path, "/")
+			trimmed.URL.RawPath = strings.TrimRight(r.URL.RawPath, "/")
+			if len(allowedMethods(router, trimmed)) > 0 {
+				r = trimmed
+			}
+		}
+		router.ServeHTTP(w, r)
+	})
+}
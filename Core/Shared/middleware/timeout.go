@@ -0,0 +1,73 @@
+package middleware
+
+import (
+	"context"
+	"fmt"
+	"github.com/gorilla/mux"
+	"golang-api-rest-swagger/Core/Shared/metrics"
+	"net/http"
+	"slices"
+	"strconv"
+	"time"
+)
+
+// RequestTimeoutHeader lets a client ask for a shorter deadline than the server default, in milliseconds.
+const RequestTimeoutHeader = "X-Request-Timeout-Ms"
+
+// Timeout sets a deadline on the request context, which the handlers pass to their database calls.
+// The deadline is max, or the value of the X-Request-Timeout-Ms header when it is a positive integer,
+// clamped to max. An absent or invalid header falls back to max.
+//
+// routes overrides max for some routes, keyed by method and path template as in
+// "GET /books/export". It must be used as router middleware, so the matched route is known.
+func Timeout(max time.Duration, routes map[string]time.Duration) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			routeMax := max
+			if d, ok := routes[metrics.RouteKey(r)]; ok {
+				routeMax = d
+			}
+			ctx, cancel := context.WithTimeout(r.Context(), requestTimeout(r, routeMax))
+			defer cancel()
+			next.ServeHTTP(w, r.WithContext(ctx))
+		})
+	}
+}
+
+// CheckRouteTimeouts returns an error naming the first route of routes, the per-route timeouts of
+// Timeout, that router does not have, so a typo in the configuration fails at startup instead of
+// being silently ignored. Call it once every route is registered.
+func CheckRouteTimeouts(router *mux.Router, routes map[string]time.Duration) error {
+	known := map[string]bool{}
+	router.Walk(func(route *mux.Route, _ *mux.Router, _ []*mux.Route) error {
+		template, err := route.GetPathTemplate()
+		if err != nil {
+			return nil
+		}
+		methods, _ := route.GetMethods()
+		for _, method := range methods {
+			known[method+" "+template] = true
+		}
+		return nil
+	})
+	keys := make([]string, 0, len(routes))
+	for key := range routes {
+		keys = append(keys, key)
+	}
+	slices.Sort(keys)
+	for _, key := range keys {
+		if !known[key] {
+			return fmt.Errorf("unknown route %q", key)
+		}
+	}
+	return nil
+}
+
+// requestTimeout returns the deadline requested in the X-Request-Timeout-Ms header, clamped to max.
+func requestTimeout(r *http.Request, max time.Duration) time.Duration {
+	ms, err := strconv.ParseInt(r.Header.Get(RequestTimeoutHeader), 10, 64)
+	if err != nil || ms <= 0 || ms > max.Milliseconds() {
+		return max
+	}
+	return time.Duration(ms) * time.Millisecond
+}
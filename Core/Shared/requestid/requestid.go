@@ -0,0 +1,56 @@
+package requestid
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"net/http"
+)
+
+// Header is the request and response header carrying the request id.
+const Header = "X-Request-ID"
+
+// maxLength caps the length of a request id accepted from a client.
+const maxLength = 128
+
+type contextKey struct{}
+
+// FromContext returns the id of the request ctx belongs to, or "" outside of a request.
+func FromContext(ctx context.Context) string {
+	id, _ := ctx.Value(contextKey{}).(string)
+	return id
+}
+
+// Middleware gives every request an id, stored in the request context and echoed in the
+// X-Request-ID response header, so log lines can be matched to a request. An id sent by the
+// client, e.g. by a proxy, is kept when it is a short printable string.
+func Middleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		id := r.Header.Get(Header)
+		if !valid(id) {
+			id = generate()
+		}
+		w.Header().Set(Header, id)
+		next.ServeHTTP(w, r.WithContext(context.WithValue(r.Context(), contextKey{}, id)))
+	})
+}
+
+// valid reports whether a client supplied id is safe to log and echo.
+func valid(id string) bool {
+	if id == "" || len(id) > maxLength {
+		return false
+	}
+	for i := 0; i < len(id); i++ {
+		if id[i] < 0x21 || id[i] > 0x7e {
+			return false
+		}
+	}
+	return true
+}
+
+// generate returns a random 128-bit id in hex.
+func generate() string {
+	b := make([]byte, 16)
+	rand.Read(b)
+	return hex.EncodeToString(b)
+}
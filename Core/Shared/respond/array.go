@@ -0,0 +1,90 @@
+package respond
+
+import (
+	"encoding/json"
+	"log"
+	"net/http"
+)
+
+// arrayFlushEvery is the number of elements written between two flushes of an array stream.
+const arrayFlushEvery = 100
+
+// JSONArrayStream writes a 200 OK JSON array response element by element, so large lists
+// are sent without being held in memory. The response has no Content-Length.
+type JSONArrayStream struct {
+	w       *countingWriter
+	r       *http.Request
+	rc      *http.ResponseController
+	written int
+}
+
+// NewJSONArrayStream starts a 200 OK JSON array response, inside a JSend success envelope
+// when the envelope is enabled.
+func NewJSONArrayStream(w http.ResponseWriter, r *http.Request) *JSONArrayStream {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	out := &countingWriter{w: w}
+	if envelope {
+		out.Write([]byte(`{"status":"success","data":`))
+	}
+	out.Write([]byte("["))
+	return &JSONArrayStream{w: out, r: r, rc: http.NewResponseController(w)}
+}
+
+// Write writes v as the next element of the array. When v would take the body past
+// MAX_RESPONSE_BYTES, the stream is aborted instead and ErrBodyTooLarge returned.
+func (s *JSONArrayStream) Write(v any) error {
+	v, err := visibleTo(s.r, v)
+	if err != nil {
+		return err
+	}
+	if naming == CamelCase {
+		converted, err := toCamelCase(v)
+		if err != nil {
+			return err
+		}
+		v = converted
+	}
+	data, err := json.Marshal(v)
+	if err != nil {
+		return err
+	}
+	if s.written > 0 {
+		data = append([]byte(","), data...)
+	}
+	// Keep room for the end of the array, so a full response never exceeds the limit.
+	if exceedsLimit(s.w.n + len(data) + len(s.end())) {
+		s.Fail(ErrBodyTooLarge)
+		return ErrBodyTooLarge
+	}
+	if _, err := s.w.Write(data); err != nil {
+		return err
+	}
+	s.written++
+	if s.written%arrayFlushEvery == 0 {
+		s.rc.Flush()
+	}
+	return nil
+}
+
+// Close ends the array, and the envelope when enabled, and records the size of the response in
+// the metrics like JSON does.
+func (s *JSONArrayStream) Close() {
+	s.w.Write(s.end())
+	s.rc.Flush()
+	observe(s.r, s.written, s.w.n)
+}
+
+// end returns the bytes closing the array, and the envelope when enabled.
+func (s *JSONArrayStream) end() []byte {
+	if envelope {
+		return []byte("]}\n")
+	}
+	return []byte("]\n")
+}
+
+// Fail ends the response without closing the array. The status code has already been sent,
+// so the truncated, invalid JSON is the only way to tell the client the list is incomplete.
+func (s *JSONArrayStream) Fail(err error) {
+	log.Printf("JSON array stream aborted after %d elements: %v", s.written, err)
+}
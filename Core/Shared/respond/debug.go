@@ -0,0 +1,41 @@
+package respond
+
+import (
+	"bytes"
+	"encoding/json"
+	"golang-api-rest-swagger/Core/Shared/debugsql"
+)
+
+// debugInfo is the _debug member added to the responses of requests asking for X-Debug-SQL.
+type debugInfo struct {
+	Queries []debugsql.Query `json:"queries"`
+}
+
+// debugWrapper carries a body that is not a JSON object, with the debug information next to it.
+type debugWrapper struct {
+	Data  any       `json:"data"`
+	Debug debugInfo `json:"_debug"`
+}
+
+// withDebug adds the debug information to v: as a leading _debug member when v is encoded as an
+// object, keeping its other members in order, or by moving v under data otherwise.
+func withDebug(v any, queries []debugsql.Query) (any, error) {
+	info := debugInfo{Queries: queries}
+	raw, err := json.Marshal(v)
+	if err != nil {
+		return nil, err
+	}
+	raw = bytes.TrimSpace(raw)
+	if len(raw) == 0 || raw[0] != '{' {
+		return debugWrapper{Data: json.RawMessage(raw), Debug: info}, nil
+	}
+	debug, err := json.Marshal(info)
+	if err != nil {
+		return nil, err
+	}
+	merged := append([]byte(`{"_debug":`), debug...)
+	if rest := bytes.TrimSpace(raw[1:]); len(rest) > 0 && rest[0] != '}' {
+		merged = append(merged, ',')
+	}
+	return json.RawMessage(append(merged, raw[1:]...)), nil
+}
@@ -0,0 +1,76 @@
+package respond
+
+import (
+	"encoding/json"
+	"net/http"
+	"strconv"
+)
+
+// envelope wraps every response in a JSend envelope when set. It is set once at startup.
+var envelope bool
+
+// SetEnvelope enables or disables the JSend envelope. When enabled, success bodies are sent as
+// {"status": "success", "data": ...}, client errors as {"status": "fail", "data": {"message": ...}}
+// and server errors as {"status": "error", "message": ...}. When disabled, success bodies are sent
+// as is and errors as plain text.
+func SetEnvelope(enabled bool) {
+	envelope = enabled
+}
+
+// success is the JSend envelope of a successful response.
+type success struct {
+	Status string `json:"status"`
+	Data   any    `json:"data"`
+}
+
+// failure is the JSend envelope of a response rejected because of the request.
+type failure struct {
+	Status string `json:"status"`
+	Data   any    `json:"data"`
+}
+
+type failureMessage struct {
+	Message string `json:"message"`
+}
+
+// serverError is the JSend envelope of a response that failed on the server.
+type serverError struct {
+	Status  string `json:"status"`
+	Message string `json:"message"`
+}
+
+// Error replies to the request with the given error message and status code. It is a drop-in
+// replacement for http.Error that follows the envelope setting.
+func Error(w http.ResponseWriter, message string, status int) {
+	if !envelope {
+		http.Error(w, message, status)
+		return
+	}
+
+	var body any = failure{Status: "fail", Data: failureMessage{Message: message}}
+	if status >= http.StatusInternalServerError {
+		body = serverError{Status: "error", Message: message}
+	}
+	data, err := json.Marshal(body)
+	if err != nil {
+		http.Error(w, message, status)
+		return
+	}
+	data = append(data, '\n')
+	h := w.Header()
+	h.Set("Content-Type", "application/json")
+	h.Set("X-Content-Type-Options", "nosniff")
+	h.Set("Content-Length", strconv.Itoa(len(data)))
+	w.WriteHeader(status)
+	w.Write(data)
+}
+
+// Fail replies to a rejected request with v, a JSON object detailing the problem, e.g. the limit a
+// client exceeded, so clients can act on it. Unlike Error, the body is JSON in both modes; with the
+// envelope enabled, v is sent as the data of a JSend fail envelope.
+func Fail(w http.ResponseWriter, r *http.Request, status int, v any) {
+	if envelope {
+		v = failure{Status: "fail", Data: v}
+	}
+	write(w, r, status, v)
+}
@@ -0,0 +1,31 @@
+package respond
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+)
+
+// maxBodyBytes is the largest JSON body sent, zero meaning no limit. It is set once at startup.
+var maxBodyBytes int64
+
+// SetMaxBodyBytes sets the largest JSON body a response may have. Buffered responses above it are
+// refused with 413; streams are cut off before the element that would exceed it. Zero disables it.
+func SetMaxBodyBytes(n int64) {
+	maxBodyBytes = n
+}
+
+// ErrBodyTooLarge is returned by the stream writers when the next element would take the body
+// past the limit set with SetMaxBodyBytes. The stream has been ended as aborted.
+var ErrBodyTooLarge = errors.New("response body exceeds MAX_RESPONSE_BYTES")
+
+// exceedsLimit reports whether a body of size bytes is larger than the limit.
+func exceedsLimit(size int) bool {
+	return maxBodyBytes > 0 && int64(size) > maxBodyBytes
+}
+
+// tooLarge refuses a buffered response whose body of size bytes is larger than the limit.
+// Nothing has been written yet.
+func tooLarge(w http.ResponseWriter, size int) {
+	Error(w, fmt.Sprintf("Response too large (%d bytes, maximum %d): paginate the request or select fewer fields", size, maxBodyBytes), http.StatusRequestEntityTooLarge)
+}
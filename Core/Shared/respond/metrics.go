@@ -0,0 +1,53 @@
+package respond
+
+import (
+	"golang-api-rest-swagger/Core/Shared/metrics"
+	"io"
+	"net/http"
+	"reflect"
+)
+
+// Sizes of the success responses per endpoint, to spot the ones returning unexpectedly large
+// bodies, such as unpaginated lists.
+var (
+	responseRows = metrics.NewHistogramMap("response_rows",
+		metrics.Description{Help: "Number of items of the successful list responses.", Label: "endpoint"},
+		0, 1, 10, 50, 100, 500, 1000, 5000)
+	responseBytes = metrics.NewHistogramMap("response_bytes",
+		metrics.Description{Help: "Size in bytes of the successful JSON response bodies.", Label: "endpoint"},
+		256, 1<<10, 4<<10, 16<<10, 64<<10, 256<<10, 1<<20, 4<<20)
+)
+
+// observe records the body size of a response, and its number of rows when it is a list, under
+// the endpoint of r. rows is negative for other bodies. Requests matching no route are skipped.
+func observe(r *http.Request, rows, bytes int) {
+	endpoint := metrics.RouteKey(r)
+	if endpoint == "" {
+		return
+	}
+	if rows >= 0 {
+		responseRows.Observe(endpoint, float64(rows))
+	}
+	responseBytes.Observe(endpoint, float64(bytes))
+}
+
+// rowsOf returns the number of elements of v when it is a slice or an array, -1 otherwise.
+func rowsOf(v any) int {
+	switch rv := reflect.ValueOf(v); rv.Kind() {
+	case reflect.Slice, reflect.Array:
+		return rv.Len()
+	}
+	return -1
+}
+
+// countingWriter counts the bytes written through it, for the metrics of streamed responses.
+type countingWriter struct {
+	w io.Writer
+	n int
+}
+
+func (c *countingWriter) Write(p []byte) (int, error) {
+	n, err := c.w.Write(p)
+	c.n += n
+	return n, err
+}
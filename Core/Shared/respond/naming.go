@@ -0,0 +1,72 @@
+package respond
+
+import (
+	"bytes"
+	"encoding/json"
+	"strings"
+)
+
+// Naming selects how the keys of JSON objects are written.
+type Naming int
+
+const (
+	// SnakeCase keeps the keys as declared on the models, e.g. created_at. It is the default.
+	SnakeCase Naming = iota
+	// CamelCase rewrites snake_case keys to camelCase, e.g. createdAt.
+	CamelCase
+)
+
+// naming is the key style applied to every response. It is set once at startup.
+var naming = SnakeCase
+
+// SetNaming sets the key style applied to every JSON response.
+func SetNaming(n Naming) {
+	naming = n
+}
+
+// toCamelCase round-trips v through JSON and rewrites every object key to camelCase.
+func toCamelCase(v any) (any, error) {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return nil, err
+	}
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.UseNumber() // keep numbers exactly as marshaled
+	var generic any
+	if err := dec.Decode(&generic); err != nil {
+		return nil, err
+	}
+	return camelizeKeys(generic), nil
+}
+
+// camelizeKeys rewrites the keys of every object nested in v.
+func camelizeKeys(v any) any {
+	switch value := v.(type) {
+	case map[string]any:
+		out := make(map[string]any, len(value))
+		for key, nested := range value {
+			out[camelize(key)] = camelizeKeys(nested)
+		}
+		return out
+	case []any:
+		for i, nested := range value {
+			value[i] = camelizeKeys(nested)
+		}
+		return value
+	default:
+		return v
+	}
+}
+
+// camelize converts a snake_case key to camelCase. Leading underscores are kept.
+func camelize(key string) string {
+	trimmed := strings.TrimLeft(key, "_")
+	prefix := key[:len(key)-len(trimmed)]
+	parts := strings.Split(trimmed, "_")
+	for i := 1; i < len(parts); i++ {
+		if parts[i] != "" {
+			parts[i] = strings.ToUpper(parts[i][:1]) + parts[i][1:]
+		}
+	}
+	return prefix + strings.Join(parts, "")
+}
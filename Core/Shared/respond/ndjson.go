@@ -0,0 +1,84 @@
+package respond
+
+import (
+	"encoding/json"
+	"log"
+	"net/http"
+)
+
+// NDJSONContentType is the media type of newline delimited JSON.
+const NDJSONContentType = "application/x-ndjson"
+
+// ndjsonFlushEvery is the number of records written between two flushes of the stream.
+const ndjsonFlushEvery = 100
+
+// NDJSONStream writes a newline delimited JSON response, one record per line, flushing
+// periodically so clients receive records while the server is still producing them.
+type NDJSONStream struct {
+	w       *countingWriter
+	r       *http.Request
+	enc     *json.Encoder
+	rc      *http.ResponseController
+	written int
+}
+
+// NewNDJSONStream starts a 200 OK newline delimited JSON response.
+func NewNDJSONStream(w http.ResponseWriter, r *http.Request) *NDJSONStream {
+	w.Header().Set("Content-Type", NDJSONContentType)
+	w.WriteHeader(http.StatusOK)
+	out := &countingWriter{w: w}
+	return &NDJSONStream{w: out, r: r, enc: json.NewEncoder(out), rc: http.NewResponseController(w)}
+}
+
+// Write writes v as the next line of the stream. When v would take the body past
+// MAX_RESPONSE_BYTES, the stream ends with an error line instead and ErrBodyTooLarge is returned.
+func (s *NDJSONStream) Write(v any) error {
+	v, err := visibleTo(s.r, v)
+	if err != nil {
+		return err
+	}
+	if naming == CamelCase {
+		converted, err := toCamelCase(v)
+		if err != nil {
+			return err
+		}
+		v = converted
+	}
+	data, err := json.Marshal(v)
+	if err != nil {
+		return err
+	}
+	data = append(data, '\n')
+	if exceedsLimit(s.w.n + len(data)) {
+		s.Fail(ErrBodyTooLarge)
+		return ErrBodyTooLarge
+	}
+	if _, err := s.w.Write(data); err != nil {
+		return err
+	}
+	s.written++
+	if s.written%ndjsonFlushEvery == 0 {
+		s.Flush()
+	}
+	return nil
+}
+
+// Fail ends the stream with a final {"error": "..."} line. The status code has already been
+// sent, so this line is the only way to tell the client the stream is incomplete.
+func (s *NDJSONStream) Fail(err error) {
+	log.Printf("NDJSON stream aborted after %d records: %v", s.written, err)
+	s.enc.Encode(map[string]string{"error": err.Error()})
+	s.Flush()
+}
+
+// Flush sends the buffered records to the client.
+func (s *NDJSONStream) Flush() {
+	s.rc.Flush()
+}
+
+// Close sends the remaining records and records the size of the response in the metrics like
+// JSON does.
+func (s *NDJSONStream) Close() {
+	s.Flush()
+	observe(s.r, s.written, s.w.n)
+}
@@ -0,0 +1,84 @@
+package respond
+
+import (
+	"bytes"
+	"encoding/json"
+	"golang-api-rest-swagger/Core/Shared/debugsql"
+	"golang-api-rest-swagger/Core/Shared/requestid"
+	"log"
+	"net/http"
+	"strconv"
+)
+
+// JSON writes v as the JSON response body with the given status code.
+// Output is compact unless the request asks for ?pretty=true, which indents it with two spaces.
+// The body is encoded in full before it is sent, so the response carries a Content-Length and an
+// encoding failure still produces a clean 500 rather than a truncated 200.
+// When the envelope is enabled, v is sent as the data of a JSend success envelope. Fields the
+// caller's role may not see are left out. A body above MAX_RESPONSE_BYTES is refused with 413. The body size, and the number of rows of lists, are
+// recorded in the response_bytes and response_rows metrics of the endpoint.
+func JSON(w http.ResponseWriter, r *http.Request, status int, v any) {
+	rows := rowsOf(v)
+	v, err := visibleTo(r, v)
+	if err != nil {
+		encodeFailed(w, r, err)
+		return
+	}
+	if envelope {
+		v = success{Status: "success", Data: v}
+	}
+	if n, ok := write(w, r, status, v); ok {
+		observe(r, rows, n)
+	}
+}
+
+// write encodes v, applying the naming and pretty settings, and sends it with the given status code.
+// It returns the size of the body and whether it was sent.
+func write(w http.ResponseWriter, r *http.Request, status int, v any) (int, bool) {
+	if naming == CamelCase {
+		converted, err := toCamelCase(v)
+		if err != nil {
+			encodeFailed(w, r, err)
+			return 0, false
+		}
+		v = converted
+	}
+	if queries, ok := debugsql.Queries(r.Context()); ok {
+		withQueries, err := withDebug(v, queries)
+		if err != nil {
+			encodeFailed(w, r, err)
+			return 0, false
+		}
+		v = withQueries
+	}
+
+	var body bytes.Buffer
+	enc := json.NewEncoder(&body)
+	if pretty, _ := strconv.ParseBool(r.URL.Query().Get("pretty")); pretty {
+		enc.SetIndent("", "  ")
+	}
+	if err := enc.Encode(v); err != nil {
+		encodeFailed(w, r, err)
+		return 0, false
+	}
+	if exceedsLimit(body.Len()) {
+		log.Printf("Refused response request_id=%s of %d bytes, above MAX_RESPONSE_BYTES", requestid.FromContext(r.Context()), body.Len())
+		tooLarge(w, body.Len())
+		return 0, false
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Content-Length", strconv.Itoa(body.Len()))
+	w.WriteHeader(status)
+	if _, err := w.Write(body.Bytes()); err != nil {
+		// The status is sent; this is most likely a client that went away.
+		log.Printf("Failed to write response request_id=%s: %v", requestid.FromContext(r.Context()), err)
+	}
+	return body.Len(), true
+}
+
+// encodeFailed logs an encoding error and replies with 500. Nothing has been written yet.
+func encodeFailed(w http.ResponseWriter, r *http.Request, err error) {
+	log.Printf("Failed to encode response request_id=%s: %v", requestid.FromContext(r.Context()), err)
+	Error(w, "Failed to encode response", http.StatusInternalServerError)
+}
@@ -0,0 +1,21 @@
+package respond
+
+import (
+	"golang-api-rest-swagger/Core/Shared/visibility"
+	"net/http"
+)
+
+// roleOf returns the role of the caller of a request, "" for anonymous callers. It is set once
+// at startup by SetRole, as the auth package depends on this one.
+var roleOf = func(*http.Request) string { return "" }
+
+// SetRole sets how the role of a request's caller is found, to hide from response bodies the
+// fields tagged with a visibility the caller does not have.
+func SetRole(f func(r *http.Request) string) {
+	roleOf = f
+}
+
+// visibleTo returns v without the fields the caller of r may not see.
+func visibleTo(r *http.Request, v any) (any, error) {
+	return visibility.Filter(v, roleOf(r))
+}
@@ -0,0 +1,92 @@
+package safefetch
+
+import (
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"net/netip"
+	"syscall"
+	"time"
+)
+
+// maxRedirects is the number of redirects followed before a fetch fails.
+const maxRedirects = 5
+
+// ErrForbiddenAddress is returned, wrapped, when a URL or one of its redirects leads to an
+// address that is not public, like loopback, private or link-local ones.
+var ErrForbiddenAddress = errors.New("address is not public")
+
+// nonPublic are the ranges that netip reports as global unicast although they are not publicly
+// routable, or that lead to an embedded IPv4 address which may be internal.
+var nonPublic = []netip.Prefix{
+	netip.MustParsePrefix("0.0.0.0/8"),       // "this network"
+	netip.MustParsePrefix("100.64.0.0/10"),   // carrier-grade NAT shared address space
+	netip.MustParsePrefix("192.0.0.0/24"),    // IETF protocol assignments
+	netip.MustParsePrefix("192.0.2.0/24"),    // documentation
+	netip.MustParsePrefix("198.18.0.0/15"),   // benchmarking
+	netip.MustParsePrefix("198.51.100.0/24"), // documentation
+	netip.MustParsePrefix("203.0.113.0/24"),  // documentation
+	netip.MustParsePrefix("240.0.0.0/4"),     // reserved
+	netip.MustParsePrefix("::/96"),           // IPv4-compatible, deprecated
+	netip.MustParsePrefix("64:ff9b::/96"),    // NAT64, e.g. 64:ff9b::7f00:1 reaches 127.0.0.1
+	netip.MustParsePrefix("64:ff9b:1::/48"),  // local-use NAT64
+	netip.MustParsePrefix("100::/64"),        // discard-only
+	netip.MustParsePrefix("2001::/32"),       // Teredo
+	netip.MustParsePrefix("2001:db8::/32"),   // documentation
+	netip.MustParsePrefix("2002::/16"),       // 6to4
+}
+
+// NewClient returns an HTTP client for fetching URLs given by API callers without letting them
+// reach the internal network. Every connection, redirects included, is checked against the
+// address it actually dials, after DNS resolution, so a host name resolving to an internal
+// address is refused too. Environment proxies are ignored, as they would hide that address.
+// Only http and https URLs are followed, and fetches give up after timeout.
+func NewClient(timeout time.Duration) *http.Client {
+	return newClient(timeout, func(addr netip.AddrPort) bool { return isPublic(addr.Addr()) })
+}
+
+// newClient returns the client of NewClient, dialing only the addresses permitted accepts.
+func newClient(timeout time.Duration, permitted func(netip.AddrPort) bool) *http.Client {
+	dialer := &net.Dialer{
+		Timeout: 10 * time.Second,
+		Control: func(network, address string, _ syscall.RawConn) error {
+			addr, err := netip.ParseAddrPort(address)
+			if err != nil || !permitted(addr) {
+				return fmt.Errorf("%s: %w", address, ErrForbiddenAddress)
+			}
+			return nil
+		},
+	}
+	return &http.Client{
+		Timeout: timeout,
+		Transport: &http.Transport{
+			Proxy:               nil,
+			DialContext:         dialer.DialContext,
+			TLSHandshakeTimeout: 10 * time.Second,
+		},
+		CheckRedirect: func(req *http.Request, via []*http.Request) error {
+			if len(via) >= maxRedirects {
+				return fmt.Errorf("stopped after %d redirects", maxRedirects)
+			}
+			if req.URL.Scheme != "http" && req.URL.Scheme != "https" {
+				return fmt.Errorf("redirect to unsupported scheme %q", req.URL.Scheme)
+			}
+			return nil
+		},
+	}
+}
+
+// isPublic reports whether addr is routable on the internet.
+func isPublic(addr netip.Addr) bool {
+	addr = addr.Unmap()
+	if !addr.IsGlobalUnicast() || addr.IsPrivate() {
+		return false
+	}
+	for _, prefix := range nonPublic {
+		if prefix.Contains(addr) {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,91 @@
+package safefetch
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"net/netip"
+	"testing"
+	"time"
+)
+
+func TestIsPublic(t *testing.T) {
+	tests := []struct {
+		addr string
+		want bool
+	}{
+		{"8.8.8.8", true},
+		{"93.184.216.34", true},
+		{"2606:4700:4700::1111", true},
+		{"::ffff:8.8.8.8", true},
+		{"127.0.0.1", false},
+		{"127.255.255.254", false},
+		{"::1", false},
+		{"0.0.0.0", false},
+		{"0.1.2.3", false},
+		{"::", false},
+		{"10.0.0.1", false},
+		{"172.16.0.1", false},
+		{"172.31.255.255", false},
+		{"192.168.1.1", false},
+		{"169.254.169.254", false},
+		{"fe80::1", false},
+		{"100.64.0.1", false},
+		{"100.127.255.255", false},
+		{"fc00::1", false},
+		{"fd12:3456::1", false},
+		{"::ffff:10.0.0.1", false},
+		{"::ffff:127.0.0.1", false},
+		{"::7f00:1", false},
+		{"64:ff9b::7f00:1", false},
+		{"64:ff9b::808:808", false},
+		{"64:ff9b:1::a00:1", false},
+		{"2002:7f00:1::", false},
+		{"2002:808:808::1", false},
+		{"2001:0:4136:e378:8000:63bf:3fff:fdd2", false},
+		{"2001:db8::1", false},
+		{"198.18.0.1", false},
+		{"198.19.255.255", false},
+		{"192.0.0.1", false},
+		{"192.0.2.1", false},
+		{"240.0.0.1", false},
+		{"255.255.255.255", false},
+		{"224.0.0.1", false},
+		{"ff02::1", false},
+	}
+	for _, tt := range tests {
+		if got := isPublic(netip.MustParseAddr(tt.addr)); got != tt.want {
+			t.Errorf("isPublic(%s) = %t, want %t", tt.addr, got, tt.want)
+		}
+	}
+}
+
+func TestClientRefusesInternalAddresses(t *testing.T) {
+	internal := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		t.Errorf("the internal server was reached: %s %s", r.Method, r.URL)
+	}))
+	defer internal.Close()
+	if _, err := NewClient(5 * time.Second).Get(internal.URL); !errors.Is(err, ErrForbiddenAddress) {
+		t.Errorf("GET %s failed with %v, want ErrForbiddenAddress", internal.URL, err)
+	}
+}
+
+func TestClientRefusesRedirectsToInternalAddresses(t *testing.T) {
+	internal := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		t.Errorf("the redirect target was reached: %s %s", r.Method, r.URL)
+	}))
+	defer internal.Close()
+	public := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.Redirect(w, r, internal.URL+"/secret", http.StatusFound)
+	}))
+	defer public.Close()
+
+	// The first server stands for a public one; any other loopback address is refused as usual.
+	publicAddr := netip.MustParseAddrPort(public.Listener.Addr().String())
+	client := newClient(5*time.Second, func(addr netip.AddrPort) bool {
+		return addr == publicAddr || isPublic(addr.Addr())
+	})
+	if _, err := client.Get(public.URL); !errors.Is(err, ErrForbiddenAddress) {
+		t.Errorf("GET %s redirecting to %s failed with %v, want ErrForbiddenAddress", public.URL, internal.URL, err)
+	}
+}
@@ -0,0 +1,50 @@
+package timestamp
+
+import (
+	"strconv"
+	"time"
+)
+
+// Format selects how the times of JSON responses are written.
+type Format int
+
+const (
+	// RFC3339 writes times as RFC 3339 strings with their fractional seconds, e.g.
+	// "2024-05-01T12:00:00.5Z". It is the default, and how time.Time marshals itself.
+	RFC3339 Format = iota
+	// Unix writes times as the number of seconds since the epoch, truncated.
+	Unix
+	// UnixMilli writes times as the number of milliseconds since the epoch, truncated.
+	UnixMilli
+)
+
+// format is the representation of every Time. It is set once at startup.
+var format = RFC3339
+
+// SetFormat sets how every Time is marshaled.
+func SetFormat(f Format) {
+	format = f
+}
+
+// Time is a time.Time marshaled to JSON in the format set with SetFormat. Database rows scan
+// into its embedded Time.
+type Time struct {
+	time.Time
+}
+
+// New returns t as a Time.
+func New(t time.Time) Time {
+	return Time{Time: t}
+}
+
+// MarshalJSON writes t in the configured format.
+func (t Time) MarshalJSON() ([]byte, error) {
+	switch format {
+	case Unix:
+		return strconv.AppendInt(nil, t.Time.Unix(), 10), nil
+	case UnixMilli:
+		return strconv.AppendInt(nil, t.Time.UnixMilli(), 10), nil
+	default:
+		return t.Time.MarshalJSON()
+	}
+}
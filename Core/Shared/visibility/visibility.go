@@ -0,0 +1,200 @@
+package visibility
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"slices"
+	"sort"
+	"strings"
+	"sync"
+)
+
+// Tag is the struct tag restricting a field to some roles, e.g. `visibility:"admin"`. It takes a
+// comma separated list of roles; fields without it are visible to everyone.
+const Tag = "visibility"
+
+// Visible reports whether role may see field.
+func Visible(field reflect.StructField, role string) bool {
+	tag, ok := field.Tag.Lookup(Tag)
+	if !ok {
+		return true
+	}
+	return slices.Contains(strings.Split(tag, ","), role)
+}
+
+// restricted caches whether a type holds, at any depth, a field with a visibility tag.
+var restricted sync.Map
+
+// hasRestricted reports whether t holds a field with a visibility tag, directly or in a nested
+// struct, pointer, slice, array or map. Values of other types are encoded as is.
+func hasRestricted(t reflect.Type) bool {
+	if cached, ok := restricted.Load(t); ok {
+		return cached.(bool)
+	}
+	// Stored before recursing so self-referencing types end the recursion.
+	restricted.Store(t, false)
+	found := false
+	switch t.Kind() {
+	case reflect.Pointer, reflect.Slice, reflect.Array, reflect.Map:
+		found = hasRestricted(t.Elem())
+	case reflect.Struct:
+		for i := 0; i < t.NumField() && !found; i++ {
+			field := t.Field(i)
+			_, tagged := field.Tag.Lookup(Tag)
+			found = field.IsExported() && (tagged || hasRestricted(field.Type))
+		}
+	}
+	restricted.Store(t, found)
+	return found
+}
+
+// Filter returns v without the fields role may not see, as encoded JSON keeping the field order
+// of encoding/json. v is returned as is when it holds no restricted field. Struct fields follow
+// the json tag names and omitempty; embedded structs are encoded as named fields, not flattened.
+func Filter(v any, role string) (any, error) {
+	if v == nil || !hasRestricted(reflect.TypeOf(v)) {
+		return v, nil
+	}
+	var buf bytes.Buffer
+	if err := encode(&buf, reflect.ValueOf(v), role); err != nil {
+		return nil, err
+	}
+	return json.RawMessage(buf.Bytes()), nil
+}
+
+// encode writes the JSON of v, without the fields role may not see, to buf.
+func encode(buf *bytes.Buffer, v reflect.Value, role string) error {
+	if !v.IsValid() {
+		buf.WriteString("null")
+		return nil
+	}
+	if !hasRestricted(v.Type()) {
+		data, err := json.Marshal(v.Interface())
+		buf.Write(data)
+		return err
+	}
+	switch v.Kind() {
+	case reflect.Pointer, reflect.Interface:
+		if v.IsNil() {
+			buf.WriteString("null")
+			return nil
+		}
+		return encode(buf, v.Elem(), role)
+	case reflect.Slice, reflect.Array:
+		if v.Kind() == reflect.Slice && v.IsNil() {
+			buf.WriteString("null")
+			return nil
+		}
+		buf.WriteByte('[')
+		for i := 0; i < v.Len(); i++ {
+			if i > 0 {
+				buf.WriteByte(',')
+			}
+			if err := encode(buf, v.Index(i), role); err != nil {
+				return err
+			}
+		}
+		buf.WriteByte(']')
+		return nil
+	case reflect.Map:
+		if v.IsNil() {
+			buf.WriteString("null")
+			return nil
+		}
+		// encoding/json sorts map keys; the keys of the maps holding models are ids and names.
+		keys := make([]string, 0, v.Len())
+		values := make(map[string]reflect.Value, v.Len())
+		for iter := v.MapRange(); iter.Next(); {
+			key := fmt.Sprint(iter.Key().Interface())
+			keys = append(keys, key)
+			values[key] = iter.Value()
+		}
+		sort.Strings(keys)
+		buf.WriteByte('{')
+		for i, key := range keys {
+			if i > 0 {
+				buf.WriteByte(',')
+			}
+			name, _ := json.Marshal(key)
+			buf.Write(name)
+			buf.WriteByte(':')
+			if err := encode(buf, values[key], role); err != nil {
+				return err
+			}
+		}
+		buf.WriteByte('}')
+		return nil
+	case reflect.Struct:
+		buf.WriteByte('{')
+		first := true
+		t := v.Type()
+		for i := 0; i < t.NumField(); i++ {
+			field := t.Field(i)
+			name, omitEmpty, ok := jsonField(field)
+			if !ok || !Visible(field, role) || (omitEmpty && isEmpty(v.Field(i))) {
+				continue
+			}
+			if !first {
+				buf.WriteByte(',')
+			}
+			first = false
+			key, _ := json.Marshal(name)
+			buf.Write(key)
+			buf.WriteByte(':')
+			if err := encode(buf, v.Field(i), role); err != nil {
+				return err
+			}
+		}
+		buf.WriteByte('}')
+		return nil
+	}
+	data, err := json.Marshal(v.Interface())
+	buf.Write(data)
+	return err
+}
+
+// jsonField returns the JSON name of an exported struct field and whether it is omitempty.
+// ok is false for the fields encoding/json skips.
+func jsonField(field reflect.StructField) (name string, omitEmpty, ok bool) {
+	if !field.IsExported() {
+		return "", false, false
+	}
+	tag := field.Tag.Get("json")
+	if tag == "-" {
+		return "", false, false
+	}
+	name, options, _ := strings.Cut(tag, ",")
+	if name == "" {
+		name = field.Name
+	}
+	return name, slices.Contains(strings.Split(options, ","), "omitempty"), true
+}
+
+// isEmpty reports whether v is empty in the sense of omitempty.
+func isEmpty(v reflect.Value) bool {
+	switch v.Kind() {
+	case reflect.Array, reflect.Map, reflect.Slice, reflect.String:
+		return v.Len() == 0
+	case reflect.Pointer, reflect.Interface:
+		return v.IsNil()
+	case reflect.Struct:
+		// omitempty never omits structs.
+		return false
+	}
+	return v.IsZero()
+}
+
+// Restore copies to dst the fields of src that role may not see, so a write by that role cannot
+// change them: with src the stored value they are kept as they are, with a zero src they are
+// cleared. dst and src must be pointers to structs of the same type.
+func Restore(dst, src any, role string) {
+	d, s := reflect.ValueOf(dst).Elem(), reflect.ValueOf(src).Elem()
+	t := d.Type()
+	for i := 0; i < t.NumField(); i++ {
+		if field := t.Field(i); field.IsExported() && !Visible(field, role) {
+			d.Field(i).Set(s.Field(i))
+		}
+	}
+}
@@ -2,7 +2,12 @@ module golang-api-rest-swagger
 
 go 1.24
 
-require github.com/gorilla/mux v1.8.1
+require (
+	github.com/go-sql-driver/mysql v1.9.2
+	github.com/gorilla/mux v1.8.1
+	github.com/joho/godotenv v1.5.1
+	github.com/swaggo/http-swagger v1.3.4
+)
 
 require (
 	filippo.io/edwards25519 v1.1.0 // indirect
@@ -11,12 +16,9 @@ require (
 	github.com/go-openapi/jsonreference v0.21.0 // indirect
 	github.com/go-openapi/spec v0.21.0 // indirect
 	github.com/go-openapi/swag v0.23.1 // indirect
-	github.com/go-sql-driver/mysql v1.9.2 // indirect
-	github.com/joho/godotenv v1.5.1 // indirect
 	github.com/josharian/intern v1.0.0 // indirect
 	github.com/mailru/easyjson v0.9.0 // indirect
 	github.com/swaggo/files v1.0.1 // indirect
-	github.com/swaggo/http-swagger v1.3.4 // indirect
 	github.com/swaggo/swag v1.16.4 // indirect
 	github.com/swaggo/swag/example/celler v0.0.0-20250321074624-93e86851e9f2 // indirect
 	golang.org/x/net v0.39.0 // indirect
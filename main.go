@@ -1,13 +1,37 @@
 package main
 
 import (
+	"context"
+	"expvar"
 	"github.com/gorilla/mux"
 	"github.com/swaggo/http-swagger"
+	adminroutes "golang-api-rest-swagger/Core/Admin/routes"
+	"golang-api-rest-swagger/Core/Books/controllers"
 	"golang-api-rest-swagger/Core/Books/database"
+	"golang-api-rest-swagger/Core/Books/models"
 	"golang-api-rest-swagger/Core/Books/routes"
+	favoriteroutes "golang-api-rest-swagger/Core/Favorites/routes"
+	"golang-api-rest-swagger/Core/Shared/auth"
+	"golang-api-rest-swagger/Core/Shared/clientip"
+	"golang-api-rest-swagger/Core/Shared/config"
+	"golang-api-rest-swagger/Core/Shared/debugsql"
+	"golang-api-rest-swagger/Core/Shared/health"
+	"golang-api-rest-swagger/Core/Shared/metrics"
+	"golang-api-rest-swagger/Core/Shared/middleware"
+	"golang-api-rest-swagger/Core/Shared/requestid"
+	"golang-api-rest-swagger/Core/Shared/respond"
+	"golang-api-rest-swagger/Core/Shared/timestamp"
 	_ "golang-api-rest-swagger/docs" // Import the generated docs
 	"log"
+	"log/slog"
+	"net"
 	"net/http"
+	"os"
+	"os/signal"
+	"strings"
+	"sync"
+	"syscall"
+	"time"
 )
 
 // main.go
@@ -22,25 +46,288 @@ import (
 // @license.url http://www.apache.org/licenses/LICENSE-2.0.html
 // @host localhost:8080
 // @BasePath /
+// @securityDefinitions.apikey ApiKeyAuth
+// @in header
+// @name X-API-Key
 func main() {
-	// Initialize database connection
-	db, err := database.InitDB() // Changed to package call
+	started := time.Now()
+
+	// Load the configuration
+	cfg, err := config.Load()
+	if err != nil {
+		log.Fatalf("Failed to load configuration: %v", err)
+	}
+
+	// Load the API keys accepted by the authenticated endpoints
+	keys, err := auth.ParseKeys(cfg.APIKeys)
+	if err != nil {
+		log.Fatalf("Invalid API_KEYS: %v", err)
+	}
+	if cfg.AdminAPIKey != "" {
+		keys[cfg.AdminAPIKey] = auth.Principal{Subject: auth.RoleAdmin, Role: auth.RoleAdmin}
+	}
+
+	// Apply the configured length limits to the validation rules and the schema of books
+	if err := models.SetMaxLength(models.Book{}, "title", cfg.Validation.MaxTitleLength); err != nil {
+		log.Fatalf("Invalid MAX_TITLE_LEN: %v", err)
+	}
+	if err := models.SetMaxLength(models.Book{}, "author", cfg.Validation.MaxAuthorLength); err != nil {
+		log.Fatalf("Invalid MAX_AUTHOR_LEN: %v", err)
+	}
+
+	// Check the default order of the book list against the sortable fields
+	if err := controllers.SetDefaultSort(cfg.Listing.DefaultSort); err != nil {
+		log.Fatalf("Invalid DEFAULT_SORT: %v", err)
+	}
+
+	// Initialize the database connections, to the primary and to the read replica if any
+	pools, err := database.InitPools(cfg.Database)
 	if err != nil {
 		log.Fatalf("Failed to initialize database: %v", err)
 	}
-	defer db.Close()
+	defer pools.Close()
+	db := pools.Primary
+
+	// Load the fixture catalog into an empty database; only binaries built with -tags seed carry one
+	seeded, err := database.Seed(context.Background(), db)
+	if err != nil {
+		log.Fatalf("Failed to seed the database: %v", err)
+	}
+	if seeded > 0 {
+		log.Printf("Seeded the database with %d books", seeded)
+	}
+
+	// Enable read-only mode when requested, rejecting all writes until it is turned off.
+	middleware.SetReadOnly(cfg.ReadOnly)
+
+	// Serialize responses with camelCase keys when requested.
+	if cfg.JSONNaming == "camel" {
+		respond.SetNaming(respond.CamelCase)
+	}
+
+	// Write times as epoch seconds or milliseconds instead of RFC 3339 when requested.
+	switch cfg.TimeFormat {
+	case "unix":
+		timestamp.SetFormat(timestamp.Unix)
+	case "unixmilli":
+		timestamp.SetFormat(timestamp.UnixMilli)
+	}
+
+	// Refuse, or cut off when streamed, JSON bodies above the configured size.
+	respond.SetMaxBodyBytes(cfg.MaxResponseBytes)
+
+	// Wrap successes and errors in a JSend envelope when requested.
+	respond.SetEnvelope(cfg.ResponseEnvelope == "jsend")
+
+	// Hide the fields restricted to other roles, such as the admin-only book notes, from callers.
+	respond.SetRole(func(r *http.Request) string {
+		principal, _ := auth.FromContext(r.Context())
+		return principal.Role
+	})
 
 	// Create a new router
 	r := mux.NewRouter()
 
+	// Attach the caller's identity, when an API key is sent, so it can be recorded as the actor of mutations
+	r.Use(auth.Identify(keys))
+
+	// Bound the database work of every request, per route when configured, optionally shortened by the
+	// client with X-Request-Timeout-Ms
+	r.Use(middleware.Timeout(cfg.RequestTimeout, cfg.RouteTimeouts))
+
+	// Background jobs run until the server has shut down, which waits for them to return
+	background, stopBackground := context.WithCancel(context.Background())
+	var jobs sync.WaitGroup
+	runJob := func(job func(context.Context)) {
+		jobs.Add(1)
+		go func() {
+			defer jobs.Done()
+			job(background)
+		}()
+	}
+
+	// Ping the database in the background so readiness probes never query it themselves
+	monitor := health.NewMonitor(db, cfg.HealthCheckInterval)
+	runJob(monitor.Run)
+	r.HandleFunc("/ready", monitor.ReadyHandler).Methods("GET")
+	r.Handle("/metrics", metrics.Handler()).Methods("GET")
+	r.Handle("/debug/vars", expvar.Handler()).Methods("GET")
+	r.HandleFunc("/time", health.TimeHandler(db)).Methods("GET")
+
+	// Permanently delete the books soft deleted longer ago than the retention period
+	if cfg.Purge.Enabled {
+		runJob(database.NewPurger(db, cfg.Purge.Interval, cfg.Purge.Retention).Run)
+	}
+
 	// Define routes using the routes package
-	routes.SetupRoutes(r, db) // Changed to package call
+	routes.SetupRoutes(r, pools, cfg, keys) // Changed to package call
+
+	if cfg.Features.Enabled(config.FeatureFavorites) {
+		favoriteroutes.SetupRoutes(r, pools, keys)
+	}
+
+	// Admin endpoints are only exposed when an admin API key is configured
+	if cfg.AdminAPIKey != "" {
+		adminroutes.SetupRoutes(r, db, cfg, keys)
+	}
+
+	// Swagger documentation endpoint, usually disabled in production
+	if cfg.Swagger.Enabled {
+		var docs http.Handler = httpSwagger.WrapHandler
+		if cfg.Swagger.User != "" {
+			docs = auth.BasicAuth(cfg.Swagger.User, cfg.Swagger.Password, "Swagger UI")(docs)
+		}
+		r.PathPrefix(cfg.Swagger.Path).Handler(docs)
+	}
 
-	// Swagger documentation endpoint
-	r.PathPrefix("/swagger/").Handler(httpSwagger.WrapHandler)
+	if err := middleware.CheckRouteTimeouts(r, cfg.RouteTimeouts); err != nil {
+		log.Fatalf("Invalid ROUTE_TIMEOUTS: %v", err)
+	}
+
+	// Answer OPTIONS with the methods allowed on the path; must stay the last route
+	r.Methods("OPTIONS").HandlerFunc(middleware.Options(r))
 
 	// Start the server
-	port := ":8080"
-	log.Println("start in port " + port)
-	log.Fatal(http.ListenAndServe(port, r))
+	// Serve /books/ like /books instead of answering 404
+	handler := middleware.TrailingSlash(r)
+	if cfg.RequireJSONContentType {
+		// Reject write bodies not declared as JSON with 415 instead of decoding them anyway
+		handler = middleware.RequireJSON(handler)
+	}
+	if cfg.LogLevel == "debug" {
+		// Log request and response bodies; never enabled by default as they may hold personal data
+		handler = middleware.LogBodies(handler)
+	}
+	if cfg.MaxConcurrentRequests > 0 {
+		// Shed load with 503 instead of letting a burst queue up on the database pool
+		handler = middleware.LimitConcurrency(cfg.MaxConcurrentRequests)(handler)
+	}
+	if len(cfg.CORS.AllowedOrigins) > 0 {
+		// Let browser frontends on the allowed origins call the API
+		handler = middleware.CORS(cfg.CORS)(handler)
+	}
+	// Return the executed SQL to requests sending X-Debug-SQL: true; never possible in production
+	handler = debugsql.Middleware(cfg.LogLevel == "debug" && cfg.Environment == "development")(handler)
+	// Tag every request with an id, echoed in X-Request-ID and used in the logs
+	handler = requestid.Middleware(handler)
+	// Find the client IP, behind the trusted proxies when there are any, for the logs
+	handler = clientip.Middleware(cfg.TrustedProxies)(handler)
+	srv := &http.Server{Addr: ":" + cfg.Port, Handler: middleware.TrackInFlight(handler)}
+	logStartupBanner(cfg, keys)
+	ln, err := net.Listen("tcp", srv.Addr)
+	if err != nil {
+		log.Fatalf("Failed to listen on %s: %v", srv.Addr, err)
+	}
+	slog.Info("server.listening", "addr", ln.Addr().String(), "startup", time.Since(started))
+	go func() {
+		if err := srv.Serve(ln); err != nil && err != http.ErrServerClosed {
+			log.Fatalf("Server failed: %v", err)
+		}
+	}()
+
+	// Wait for a termination signal, then drain the in-flight requests
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+	<-ctx.Done()
+	shutdown(srv, cfg.ShutdownTimeout)
+	stopBackground()
+	jobs.Wait()
+}
+
+// shutdown stops accepting new connections and waits up to timeout for the in-flight requests
+// to finish. Connections still open after the timeout are closed forcibly.
+func shutdown(srv *http.Server, timeout time.Duration) {
+	slog.Info("server.shutdown.initiated", "timeout", timeout, "in_flight", middleware.InFlightRequests())
+	start := time.Now()
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+	if err := srv.Shutdown(ctx); err != nil {
+		log.Printf("Shutdown timed out with %d requests still in flight, closing remaining connections", middleware.InFlightRequests())
+		srv.Close()
+		slog.Info("server.shutdown.complete", "duration", time.Since(start), "forced", true)
+		return
+	}
+	slog.Info("server.shutdown.complete", "duration", time.Since(start), "forced", false)
+}
+
+// logStartupBanner logs the effective settings as the attributes of a single record, so operators
+// can confirm the configuration at a glance. Secrets are never logged.
+func logStartupBanner(cfg config.Config, keys auth.Keys) {
+	db := cfg.Database
+	slog.Info("starting server",
+		"port", cfg.Port,
+		// Database.
+		"db_host", db.Host,
+		"db_port", db.Port,
+		"db_name", db.Name,
+		"db_user", db.User,
+		"db_password", redact(db.Password),
+		"db_read_host", db.ReadHost,
+		"read_your_writes", db.ReadYourWrites,
+		"table_prefix", db.TablePrefix,
+		"db_params", db.Params,
+		"unique_title_author", db.UniqueTitleAuthor,
+		"run_migrations", db.RunMigrations,
+		"pool_max_open", db.MaxOpenConns,
+		"pool_max_idle", db.MaxIdleConns,
+		"pool_conn_max_lifetime", db.ConnMaxLifetime,
+		// Server.
+		"shutdown_timeout", cfg.ShutdownTimeout,
+		"request_timeout", cfg.RequestTimeout,
+		"route_timeouts", len(cfg.RouteTimeouts),
+		"health_check_interval", cfg.HealthCheckInterval,
+		"max_concurrent_requests", cfg.MaxConcurrentRequests,
+		"max_response_bytes", cfg.MaxResponseBytes,
+		"trusted_proxies", len(cfg.TrustedProxies),
+		// Listing.
+		"max_unpaginated_results", cfg.Listing.MaxUnpaginatedResults,
+		"max_page_size", cfg.Listing.MaxPageSize,
+		"reject_oversized_pages", cfg.Listing.RejectOversizedPages,
+		"empty_no_content", cfg.Listing.EmptyNoContent,
+		"default_sort", cfg.Listing.DefaultSort,
+		"seek_offset_threshold", cfg.Listing.SeekOffset,
+		"stream_threshold", cfg.Listing.StreamThreshold,
+		// Bulk and import.
+		"max_bulk_items", cfg.Bulk.MaxItems,
+		"max_bulk_body_bytes", cfg.Bulk.MaxBodyBytes,
+		"import_url_timeout", cfg.Import.Timeout,
+		"import_url_max_bytes", cfg.Import.MaxBytes,
+		// Validation.
+		"max_title_len", cfg.Validation.MaxTitleLength,
+		"max_author_len", cfg.Validation.MaxAuthorLength,
+		// Purge.
+		"purge", cfg.Purge.Enabled,
+		"purge_interval", cfg.Purge.Interval,
+		"purge_retention", cfg.Purge.Retention,
+		// CORS and caching.
+		"cors_origins", strings.Join(cfg.CORS.AllowedOrigins, ","),
+		"cors_credentials", cfg.CORS.AllowCredentials,
+		"cors_max_age", cfg.CORS.MaxAge,
+		"cache_max_age", cfg.CacheMaxAge,
+		// Responses.
+		"json_naming", cfg.JSONNaming,
+		"time_format", cfg.TimeFormat,
+		"response_envelope", cfg.ResponseEnvelope,
+		// Access.
+		"auth", len(keys) > 0,
+		"admin", cfg.AdminAPIKey != "",
+		"read_only", cfg.ReadOnly,
+		"put_upsert", cfg.PutUpsert,
+		"require_json", cfg.RequireJSONContentType,
+		"features", cfg.Features.String(),
+		// Runtime.
+		"log_level", cfg.LogLevel,
+		"app_env", cfg.Environment,
+		"swagger", cfg.Swagger.Enabled,
+		"swagger_path", cfg.Swagger.Path,
+		"swagger_auth", cfg.Swagger.User != "",
+	)
+}
+
+// redact hides a secret while still showing whether it is set.
+func redact(secret string) string {
+	if secret == "" {
+		return ""
+	}
+	return "[REDACTED]"
 }